@@ -0,0 +1,4 @@
+// Package queuev1 holds the types generated from queue.proto.
+package queuev1
+
+//go:generate protoc --proto_path=../.. --go_out=../.. --go_opt=paths=source_relative queue/v1/queue.proto
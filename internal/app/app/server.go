@@ -5,13 +5,19 @@ import (
 	"errors"
 	"github.com/StratuStore/file-storage/internal/app/connector"
 	"github.com/StratuStore/file-storage/internal/app/controller"
+	"github.com/StratuStore/file-storage/internal/app/fsm"
 	"github.com/StratuStore/file-storage/internal/app/handlers/queue"
 	"github.com/StratuStore/file-storage/internal/app/handlers/rest"
+	"github.com/StratuStore/file-storage/internal/app/ingest"
+	"github.com/StratuStore/file-storage/internal/app/replication"
+	"github.com/StratuStore/file-storage/internal/app/signing"
 	"github.com/StratuStore/file-storage/internal/app/usecases"
 	"github.com/StratuStore/file-storage/internal/libs/config"
 	"github.com/StratuStore/file-storage/internal/libs/log"
+	"github.com/google/uuid"
 	"golang.org/x/sync/errgroup"
 	"log/slog"
+	"net/netip"
 	"os"
 	"os/signal"
 	"syscall"
@@ -29,17 +35,97 @@ func Run() {
 	filesConnector := connector.NewConnector[*usecases.FileWithHost]()
 	readersConnector := connector.NewConnector[usecases.Reader]()
 
-	filesController, err := controller.NewController(cfg.StoragePath, cfg.StorageSize)
+	layout, err := controller.NewLayout(cfg.ShardDepth, cfg.ShardWidth)
 	if err != nil {
 		panic(err)
 	}
 
-	useCases := usecases.NewUseCases(filesConnector, readersConnector, filesController, l, cfg.MinBufferSize, cfg.MaxBufferSize, cfg.Token)
+	placement, err := controller.NewPlacement(cfg.Placement)
+	if err != nil {
+		panic(err)
+	}
+
+	watermarks, err := controller.NewWatermarks(cfg.LowWatermark, cfg.HighWatermark)
+	if err != nil {
+		panic(err)
+	}
+
+	disks := []*controller.Disk{controller.NewDisk(cfg.StoragePath, cfg.StorageSize)}
+	if len(cfg.Disks) > 0 {
+		disks = disks[:0]
+		for _, disk := range cfg.Disks {
+			d := controller.NewDisk(disk.Path, disk.Size)
+			d.Cold = disk.Cold
+			disks = append(disks, d)
+		}
+	}
+
+	filesController, err := controller.NewController(disks, layout, placement, watermarks)
+	if err != nil {
+		panic(err)
+	}
+	for _, disk := range filesController.Disks {
+		if !disk.Available() {
+			l.Warn("disk is unavailable", slog.String("path", disk.Path))
+		}
+	}
+	if cfg.ErasureDataShards > 0 {
+		err := filesController.EnableErasureCoding(controller.ErasureCoding{DataShards: int(cfg.ErasureDataShards), ParityShards: int(cfg.ErasureParityShards)})
+		if err != nil {
+			panic(err)
+		}
+	}
+	if filesController.ReadOnly() {
+		l.Warn("storage usage is above the high watermark, starting in read-only mode", slog.Float64("usage", filesController.Usage()))
+	}
+
+	replicator := replication.New(cfg.RabbitMQ.Host, cfg.Token, cfg.Replication.Timeout, cfg.MaxBufferSize)
+	fsmClient := fsm.New(fsm.Options{
+		Token:            cfg.Token,
+		Timeout:          cfg.FSM.Timeout,
+		Retries:          cfg.FSM.Retries,
+		Backoff:          cfg.FSM.Backoff,
+		MaxBackoff:       cfg.FSM.MaxBackoff,
+		BreakerThreshold: cfg.FSM.BreakerThreshold,
+		BreakerCooldown:  cfg.FSM.BreakerCooldown,
+	})
+	useCases := usecases.NewUseCases(filesConnector, readersConnector, filesController, replicator, fsmClient, l, cfg.MinBufferSize, cfg.MaxBufferSize, cfg.PromoteOnAccess)
+	useCases.Downloader = ingest.New(ingest.Options{
+		Timeout:      cfg.Ingest.Timeout,
+		MaxRedirects: cfg.Ingest.MaxRedirects,
+		AllowPrivate: cfg.Ingest.AllowPrivate,
+	})
 	handler := rest.NewHandler(useCases, l, cfg)
-	queueHandler, err := queue.New(l, cfg, useCases, filesController)
+	// urls are signed with the exchange token unless dedicated keys are configured
+	signingKeys := cfg.Signing.Keys
+	if len(signingKeys) == 0 && cfg.Token != "" {
+		signingKeys = map[string]string{"": cfg.Token}
+	}
+	if len(signingKeys) > 0 {
+		handler.Signer, err = signing.NewSigner(signingKeys, cfg.Signing.KeyID)
+		if err != nil {
+			panic(err)
+		}
+	} else {
+		l.Warn("no signing keys, signed urls are disabled")
+	}
+	for _, cidr := range cfg.Signing.TrustedProxies {
+		proxy, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			panic(err)
+		}
+		handler.TrustedProxies = append(handler.TrustedProxies, proxy)
+	}
+	transport, err := queue.NewTransport(l, cfg)
 	if err != nil {
 		panic(err)
 	}
+	queueHandler, err := queue.New(l, cfg, useCases, filesController, fsmClient, transport)
+	if err != nil {
+		panic(err)
+	}
+	useCases.Notifier = queueHandler
+	handler.DeadLetters = queueHandler
 
 	// Graceful shutdown context
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
@@ -51,6 +137,40 @@ func Run() {
 		return handler.Start(ctx)
 	})
 
+	filesController.StartDiskCheckRoutine(cfg.DiskCheckInterval, func(disk *controller.Disk, files []uuid.UUID) {
+		notification := &queue.Notification{Type: queue.AvailableNotification, FileIDs: files}
+		if !disk.Available() {
+			notification.Type = queue.UnavailableNotification
+		}
+		l.Warn("disk availability has changed", slog.String("path", disk.Path), slog.Bool("available", disk.Available()), slog.Int("files", len(files)))
+
+		if err := queueHandler.Notify(ctx, notification); err != nil {
+			l.Error("unable to notify fsm about disk availability", slog.String("err", err.Error()))
+		}
+	})
+
+	filesController.StartSpaceCheckRoutine(cfg.SpaceCheckInterval, func(readOnly bool) {
+		if readOnly {
+			l.Warn("storage usage has crossed the high watermark, switching to read-only mode", slog.Float64("usage", filesController.Usage()))
+		} else {
+			l.Info("storage usage has dropped below the low watermark, accepting uploads again", slog.Float64("usage", filesController.Usage()))
+		}
+	})
+
+	filesController.StartExpirationRoutine(time.Duration(cfg.ExpirationSleepInMinutes)*time.Minute, func(deleted []uuid.UUID, err error) {
+		if err != nil {
+			l.Error("unable to delete some expired files", slog.String("err", err.Error()))
+		}
+		if len(deleted) == 0 {
+			return
+		}
+		l.Info("expired files have been deleted", slog.Int("files", len(deleted)))
+
+		if err := queueHandler.Notify(ctx, &queue.Notification{Type: queue.ExpiredNotification, FileIDs: deleted}); err != nil {
+			l.Error("unable to notify fsm about expired files", slog.String("err", err.Error()))
+		}
+	})
+
 	g.Go(func() error {
 		filesConnector.StartDisposalRoutine(time.Duration(cfg.GC.SleepInMinutes)*time.Minute, time.Duration(cfg.GC.KeepAliveInMinutes)*time.Minute)
 		readersConnector.StartDisposalRoutine(time.Duration(cfg.GC.SleepInMinutes)*time.Minute, time.Duration(cfg.GC.KeepAliveInMinutes)*time.Minute)
@@ -62,6 +182,28 @@ func Run() {
 		return queueHandler.Start(ctx)
 	})
 
+	g.Go(func() error {
+		return queueHandler.StartHeartbeat(gCtx, cfg.HeartbeatInterval)
+	})
+
+	if filesController.HasColdTier() {
+		g.Go(func() error {
+			return migrateTiers(gCtx, l, filesController, time.Duration(cfg.ColdAfterInDays)*24*time.Hour, time.Duration(cfg.Tiering.SleepInMinutes)*time.Minute)
+		})
+	}
+
+	if filesController.ErasureCoding().DataShards > 0 {
+		g.Go(func() error {
+			return rebuildShards(gCtx, l, filesController, time.Duration(cfg.RebuildSleepInMinutes)*time.Minute, time.Duration(cfg.ScrubIntervalInHours)*time.Hour)
+		})
+	}
+
+	if cfg.MigrateLayout {
+		g.Go(func() error {
+			return migrateLayout(gCtx, l, filesController, time.Duration(cfg.MigrationSleepInMinutes)*time.Minute)
+		})
+	}
+
 	g.Go(func() error {
 		<-gCtx.Done()
 
@@ -74,3 +216,71 @@ func Run() {
 		l.Error("server shutdown", slog.String("err", err.Error()))
 	}
 }
+
+// migrateLayout moves blobs into the configured layout, retrying busy files after sleep.
+func migrateLayout(ctx context.Context, l *slog.Logger, ctrl *controller.Controller, sleep time.Duration) error {
+	l = l.With(slog.String("op", "internal.app.app.migrateLayout"))
+
+	for {
+		moved, pending, err := ctrl.MigrateLayout()
+		if err != nil {
+			l.Error("unable to move some files", slog.String("err", err.Error()))
+		}
+		l.Info("layout migration pass finished", slog.Int("moved", moved), slog.Int("pending", pending))
+
+		if pending == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(sleep):
+		}
+	}
+}
+
+// migrateTiers periodically moves files which haven't been accessed for coldAfter to the cold tier.
+func migrateTiers(ctx context.Context, l *slog.Logger, ctrl *controller.Controller, coldAfter, sleep time.Duration) error {
+	l = l.With(slog.String("op", "internal.app.app.migrateTiers"))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(sleep):
+		}
+
+		moved, pending, err := ctrl.MigrateTiers(coldAfter)
+		if err != nil {
+			l.Error("unable to move some files to the cold tier", slog.String("err", err.Error()))
+		}
+		l.Info("tiering pass finished", slog.Int("moved", moved), slog.Int("pending", pending))
+	}
+}
+
+// rebuildShards verifies the shards against their checksums every scrub, the other runs only look for missing ones.
+func rebuildShards(ctx context.Context, l *slog.Logger, ctrl *controller.Controller, sleep, scrub time.Duration) error {
+	l = l.With(slog.String("op", "internal.app.app.rebuildShards"))
+
+	var scrubbedAt time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(sleep):
+		}
+
+		verify := scrub > 0 && time.Since(scrubbedAt) >= scrub
+		if verify {
+			scrubbedAt = time.Now()
+		}
+		rebuilt, pending, err := ctrl.RebuildShards(verify)
+		if err != nil {
+			l.Error("unable to rebuild some shards", slog.String("err", err.Error()))
+		}
+		if rebuilt > 0 || pending > 0 {
+			l.Warn("lost shards have been rebuilt", slog.Int("rebuilt", rebuilt), slog.Int("pending", pending))
+		}
+	}
+}
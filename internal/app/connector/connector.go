@@ -52,6 +52,11 @@ func (c *Connector[V]) Connection(id uuid.UUID) (V, error) {
 	return value, err
 }
 
+// Len returns the number of open connections, including expired ones which haven't been disposed yet.
+func (c *Connector[V]) Len() int {
+	return c.m.Len()
+}
+
 func (c *Connector[V]) StartDisposalRoutine(sleep time.Duration, timeout time.Duration) {
 	go func() {
 		for {
@@ -2,55 +2,205 @@ package controller
 
 import (
 	"errors"
+	"fmt"
 	"github.com/StratuStore/file-storage/internal/app/fileio"
 	"github.com/google/uuid"
 	"os"
+	"path"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
-var ErrMaxSizeExceeded = errors.New("max size exceeded")
+var (
+	ErrMaxSizeExceeded   = errors.New("max size exceeded")
+	ErrReadOnly          = errors.New("node is read-only: storage usage has crossed the high watermark")
+	ErrInvalidWatermarks = errors.New("watermarks must satisfy 0 < low <= high <= 1")
+	ErrNoOwner           = errors.New("owner id is empty")
+	ErrDraining          = errors.New("node is draining: it accepts no new files")
+)
+
+// Watermarks are fractions of the storage usage switching the node into and out of the read-only mode.
+type Watermarks struct {
+	Low  float64
+	High float64
+}
+
+func NewWatermarks(low, high float64) (Watermarks, error) {
+	if low <= 0 || low > high || high > 1 {
+		return Watermarks{}, ErrInvalidWatermarks
+	}
+
+	return Watermarks{Low: low, High: high}, nil
+}
 
 type Controller struct {
 	FileSystem
 	MaxSize     int64
 	CurrentSize atomic.Int64
 	Files       map[uuid.UUID]fileio.File
-	path        string
-	mx          *sync.RWMutex
+	Disks       []*Disk
+	accounts    map[uuid.UUID]*account
+	owners      map[string]*Owner
+	placement   Placement
+	layout      Layout
+	watermarks  Watermarks
+	erasure     ErasureCoding
+	// shards are found on the disks being loaded, they are grouped by file until every disk is loaded
+	shards   map[uuid.UUID]map[int]shard
+	readOnly atomic.Bool
+	draining atomic.Bool
+	mx       *sync.RWMutex
 }
 
-func NewController(path string, maxSize int64) (*Controller, error) {
+// NewController loads files from every disk, disks that can't be read are marked as unavailable.
+func NewController(disks []*Disk, layout Layout, placement Placement, watermarks Watermarks) (*Controller, error) {
 	controller := &Controller{
 		FileSystem:  osFs{},
-		MaxSize:     maxSize,
 		CurrentSize: atomic.Int64{},
-		Files:       nil,
-		path:        path,
+		Files:       make(map[uuid.UUID]fileio.File),
+		Disks:       disks,
+		accounts:    make(map[uuid.UUID]*account),
+		owners:      make(map[string]*Owner),
+		shards:      make(map[uuid.UUID]map[int]shard),
+		placement:   placement,
+		layout:      layout,
+		watermarks:  watermarks,
 		mx:          &sync.RWMutex{},
 	}
 
-	files, err := controller.FileSystem.ListDir(path)
-	if err != nil {
-		return nil, err
-	}
-	err = controller.parseStorage(path, files)
-	if err != nil {
-		return nil, err
+	var available int
+	for _, disk := range disks {
+		disk.Controller = controller
+		controller.MaxSize += disk.MaxSize
+
+		err := controller.loadDisk(disk)
+		if errors.Is(err, ErrMaxSizeExceeded) {
+			return nil, err
+		}
+		if err != nil {
+			disk.unavailable.Store(true)
+			continue
+		}
+
+		available++
 	}
-	if controller.CurrentSize.Load() > maxSize {
-		return nil, ErrMaxSizeExceeded
+	if available == 0 {
+		return nil, ErrNoDiskAvailable
 	}
+	controller.loadShardedFiles()
+	controller.CheckSpace()
 
 	return controller, nil
 }
 
+// ReadOnly reports whether the node rejects new uploads because the high watermark has been crossed.
+func (c *Controller) ReadOnly() bool {
+	return c.readOnly.Load()
+}
+
+// Draining reports whether the node is being decommissioned: it accepts no new files and gives its files away.
+func (c *Controller) Draining() bool {
+	return c.draining.Load()
+}
+
+func (c *Controller) SetDraining(draining bool) {
+	c.draining.Store(draining)
+}
+
+// FileIDs returns ids of all files of the node.
+func (c *Controller) FileIDs() []uuid.UUID {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	ids := make([]uuid.UUID, 0, len(c.Files))
+	for id := range c.Files {
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// Free returns the number of bytes left on all available disks, taking the real free space into account.
+func (c *Controller) Free() (free int64) {
+	for _, disk := range c.Disks {
+		free += disk.Free()
+	}
+
+	return free
+}
+
+// Usage returns the used fraction of the available disks, taking the real free space into account.
+func (c *Controller) Usage() float64 {
+	var capacity int64
+	for _, disk := range c.Disks {
+		if disk.Available() {
+			capacity += disk.MaxSize
+		}
+	}
+	if capacity == 0 {
+		return 1
+	}
+
+	return 1 - float64(c.Free())/float64(capacity)
+}
+
+// CheckSpace refreshes the free space of the disks and reports whether the read-only mode has changed.
+func (c *Controller) CheckSpace() (changed bool) {
+	for _, disk := range c.Disks {
+		if disk.Available() {
+			_ = disk.refreshFree()
+		}
+	}
+
+	return c.updateMode()
+}
+
+// StartSpaceCheckRoutine periodically checks the free space and reports every switch of the read-only mode.
+func (c *Controller) StartSpaceCheckRoutine(sleep time.Duration, report func(readOnly bool)) {
+	go func() {
+		reported := c.ReadOnly()
+		for {
+			time.Sleep(sleep)
+			c.CheckSpace()
+
+			// the mode may also be switched by allocations in between the checks
+			if readOnly := c.ReadOnly(); readOnly != reported {
+				reported = readOnly
+				report(readOnly)
+			}
+		}
+	}()
+}
+
+func (c *Controller) updateMode() (changed bool) {
+	usage := c.Usage()
+
+	if usage >= c.watermarks.High {
+		return c.readOnly.CompareAndSwap(false, true)
+	}
+	if usage < c.watermarks.Low {
+		return c.readOnly.CompareAndSwap(true, false)
+	}
+
+	return false
+}
+
+// TryAllocateStorage checks whether a file of the given size fits into any of the available hot disks.
 func (c *Controller) TryAllocateStorage(size int64) error {
-	if c.CurrentSize.Load()+size > c.MaxSize {
-		return ErrMaxSizeExceeded
+	if c.ReadOnly() {
+		return ErrReadOnly
 	}
 
-	return nil
+	for _, disk := range c.tierDisks(false) {
+		if disk.Free() >= size {
+			return nil
+		}
+	}
+
+	return ErrMaxSizeExceeded
 }
 
 func (c *Controller) AllocateStorage(size int64) error {
@@ -70,7 +220,7 @@ func (c *Controller) ReleaseStorage(size int64) error {
 }
 
 func (c *Controller) AllocateAll() (n int, err error) {
-	if result := c.MaxSize - c.CurrentSize.Load(); result > 0 {
+	if result := min(c.MaxSize-c.CurrentSize.Load(), c.Free()); result > 0 {
 		return int(result), err
 	}
 
@@ -78,31 +228,192 @@ func (c *Controller) AllocateAll() (n int, err error) {
 }
 
 func (c *Controller) AddFile(id uuid.UUID) (fileio.File, error) {
-	file, err := fileio.NewFile(c.path, id, c)
+	file, _, err := c.addFile(id, 0, nil)
+
+	return file, err
+}
+
+// AddReservedFile places a new file on a disk which fits the whole upload and reserves size bytes for it.
+func (c *Controller) AddReservedFile(id uuid.UUID, size int64, metadata fileio.Metadata) (fileio.File, fileio.Reservation, error) {
+	if c.ReadOnly() {
+		return nil, nil, ErrReadOnly
+	}
+
+	owner := c.owner(metadata.OwnerID)
+	if err := owner.reserve(size, true); err != nil {
+		return nil, nil, err
+	}
+
+	file, acc, err := c.addFile(id, size, owner)
 	if err != nil {
+		owner.unreserve(size)
+		owner.remove(0)
+		return nil, nil, err
+	}
+
+	reservation, err := acc.Reserve(size)
+	if err != nil {
+		owner.unreserve(size)
+		return nil, nil, errors.Join(err, c.DeleteFile(id))
+	}
+	reservation.owner = owner
+
+	if err := file.SetMetadata(metadata); err != nil {
+		reservation.Release()
+		return nil, nil, errors.Join(err, c.DeleteFile(id))
+	}
+
+	return file, reservation, nil
+}
+
+// ReserveFile reserves the growth of the file, the reservation takes the current content over once it is released.
+func (c *Controller) ReserveFile(id uuid.UUID, size int64) (fileio.Reservation, error) {
+	if c.ReadOnly() {
+		return nil, ErrReadOnly
+	}
+
+	c.mx.RLock()
+	acc, ok := c.accounts[id]
+	file := c.Files[id]
+	c.mx.RUnlock()
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+
+	acc.rewriteMx.Lock()
+	defer acc.rewriteMx.Unlock()
+	// shards live on other disks, and a pending rewrite takes the content over already
+	rewrite := acc.rewrite == nil && !acc.sharded()
+	if rewrite {
+		size = max(size-file.Size(), 0)
+	}
+
+	if err := acc.owner.reserve(size, false); err != nil {
 		return nil, err
 	}
 
+	reservation, err := acc.Reserve(size)
+	if err != nil {
+		acc.owner.unreserve(size)
+		return nil, err
+	}
+	reservation.owner = acc.owner
+	if rewrite {
+		reservation.acc = acc
+		acc.rewrite = reservation
+	}
+
+	return reservation, nil
+}
+
+// Reserved returns the space promised to announced uploads on all disks.
+func (c *Controller) Reserved() (reserved int64) {
+	for _, disk := range c.Disks {
+		reserved += disk.Reserved.Load()
+	}
+
+	return reserved
+}
+
+// SetQuota replaces the quota of the owner.
+func (c *Controller) SetQuota(ownerID string, quota Quota) error {
+	if ownerID == "" {
+		return ErrNoOwner
+	}
+
+	c.owner(ownerID).setQuota(quota)
+
+	return nil
+}
+
+// OwnerUsage returns the usage of the owner on this node.
+func (c *Controller) OwnerUsage(ownerID string) (OwnerUsage, error) {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	owner, ok := c.owners[ownerID]
+	if !ok {
+		return OwnerUsage{}, os.ErrNotExist
+	}
+
+	return owner.Usage(), nil
+}
+
+// OwnersUsage returns the usage of every owner known to this node, sorted by owner id.
+func (c *Controller) OwnersUsage() []OwnerUsage {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	usage := make([]OwnerUsage, 0, len(c.owners))
+	for _, owner := range c.owners {
+		usage = append(usage, owner.Usage())
+	}
+	slices.SortFunc(usage, func(a, b OwnerUsage) int {
+		return strings.Compare(a.OwnerID, b.OwnerID)
+	})
+
+	return usage
+}
+
+func (c *Controller) owner(id string) *Owner {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	return c.ownerLocked(id)
+}
+
+// ownerLocked returns the owner with the given id, creating it if needed. c.mx must be locked.
+func (c *Controller) ownerLocked(id string) *Owner {
+	if id == "" {
+		return nil
+	}
+
+	owner, ok := c.owners[id]
+	if !ok {
+		owner = &Owner{id: id}
+		c.owners[id] = owner
+	}
+
+	return owner
+}
+
+func (c *Controller) addFile(id uuid.UUID, size int64, owner *Owner) (fileio.File, *account, error) {
 	c.mx.Lock()
 	defer c.mx.Unlock()
 	if _, ok := c.Files[id]; ok {
-		return nil, os.ErrExist
+		return nil, nil, os.ErrExist
+	}
+
+	disk, err := c.placement.Pick(c.tierDisks(false), size)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	acc := &account{Disk: disk, owner: owner}
+	file, err := fileio.NewFile(c.layout.Dir(disk.Path, id), id, acc)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	c.Files[id] = file
+	c.accounts[id] = acc
 
-	return file, nil
+	return file, acc, nil
 }
 
+// DeleteFile removes the file and gives its space back to the disk and the owner.
 func (c *Controller) DeleteFile(id uuid.UUID) error {
 	c.mx.Lock()
 
 	file, ok := c.Files[id]
 	if !ok {
+		c.mx.Unlock()
 		return os.ErrNotExist
 	}
+	acc := c.accounts[id]
 
 	delete(c.Files, id)
+	delete(c.accounts, id)
 
 	c.mx.Unlock()
 
@@ -110,37 +421,205 @@ func (c *Controller) DeleteFile(id uuid.UUID) error {
 		return err
 	}
 
+	acc.owner.remove(file.Size())
+	_ = acc.releaseDisks(file.Size())
+
 	return nil
 }
 
+// File returns the file with the given id. Expired files are treated as missing even before they are deleted.
 func (c *Controller) File(id uuid.UUID) (fileio.File, error) {
 	c.mx.RLock()
 	defer c.mx.RUnlock()
 
-	if file, ok := c.Files[id]; ok {
+	if file, ok := c.Files[id]; ok && !file.Metadata().Expired(time.Now()) {
+		if !c.accounts[id].Available() {
+			return nil, ErrDiskUnavailable
+		}
+
 		return file, nil
 	}
 
 	return nil, os.ErrNotExist
 }
 
-func (c *Controller) parseStorage(path string, files map[string]int64) (globalErr error) {
-	c.Files = make(map[uuid.UUID]fileio.File, len(files))
+// ExpiredFiles returns ids of the files whose expiry time has passed by now.
+func (c *Controller) ExpiredFiles(now time.Time) []uuid.UUID {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	var ids []uuid.UUID
+	for id, file := range c.Files {
+		if file.Metadata().Expired(now) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// DeleteExpired deletes the expired files and returns ids of the deleted ones.
+func (c *Controller) DeleteExpired(now time.Time) (deleted []uuid.UUID, err error) {
+	for _, id := range c.ExpiredFiles(now) {
+		switch err2 := c.DeleteFile(id); {
+		case errors.Is(err2, os.ErrNotExist):
+			// file has been deleted in the meantime
+		case err2 != nil:
+			err = errors.Join(err, err2)
+		default:
+			deleted = append(deleted, id)
+		}
+	}
+
+	return deleted, err
+}
+
+// StartExpirationRoutine periodically deletes expired files and reports their ids.
+func (c *Controller) StartExpirationRoutine(sleep time.Duration, report func(deleted []uuid.UUID, err error)) {
+	go func() {
+		for {
+			time.Sleep(sleep)
+			if deleted, err := c.DeleteExpired(time.Now()); len(deleted) > 0 || err != nil {
+				report(deleted, err)
+			}
+		}
+	}()
+}
+
+// DiskFiles returns ids of all files placed on the disk.
+func (c *Controller) DiskFiles(disk *Disk) []uuid.UUID {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	var ids []uuid.UUID
+	for id, acc := range c.accounts {
+		// erasure coded files survive the disk as long as enough shards are left
+		if acc.Disk == disk && !(acc.sharded() && acc.Available()) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// CheckDisks probes every disk and returns those whose availability has changed.
+func (c *Controller) CheckDisks() (changed []*Disk) {
+	for _, disk := range c.Disks {
+		var available bool
+		if disk.loaded {
+			available = disk.probe()
+		} else {
+			available = c.loadDisk(disk) == nil
+			if available {
+				c.loadShardedFiles()
+			}
+		}
+
+		if available != disk.Available() {
+			disk.unavailable.Store(!available)
+			changed = append(changed, disk)
+		}
+	}
+
+	return changed
+}
+
+// StartDiskCheckRoutine periodically probes disks and reports files of every disk whose availability has changed.
+func (c *Controller) StartDiskCheckRoutine(sleep time.Duration, report func(disk *Disk, files []uuid.UUID)) {
+	go func() {
+		for {
+			time.Sleep(sleep)
+			for _, disk := range c.CheckDisks() {
+				report(disk, c.DiskFiles(disk))
+			}
+		}
+	}()
+}
+
+// MigrateLayout moves every blob into the configured layout, busy files are skipped and counted as pending.
+func (c *Controller) MigrateLayout() (moved, pending int, err error) {
+	c.mx.RLock()
+	files := make(map[fileio.File]*Disk, len(c.Files))
+	for id, file := range c.Files {
+		files[file] = c.accounts[id].Disk
+	}
+	c.mx.RUnlock()
+
+	for file, disk := range files {
+		dir := c.layout.Dir(disk.Path, file.ID())
+		if !disk.Available() || path.Dir(file.FullPath()) == dir {
+			continue
+		}
+
+		switch err2 := file.Relocate(dir); {
+		case errors.Is(err2, fileio.ErrBusy):
+			pending++
+		case errors.Is(err2, os.ErrClosed):
+			// file has been deleted in the meantime
+		case err2 != nil:
+			err = errors.Join(err, err2)
+		default:
+			moved++
+		}
+	}
+
+	return moved, pending, err
+}
+
+func (c *Controller) loadDisk(disk *Disk) error {
+	files, err := c.FileSystem.ListDir(disk.Path, c.layout.Depth)
+	if err != nil {
+		return err
+	}
+	if err := disk.mark(); err != nil {
+		return err
+	}
+
+	err = c.parseStorage(disk, files)
+	if err != nil {
+		return err
+	}
+	disk.loaded = true
+
+	if disk.CurrentSize.Load() > disk.MaxSize {
+		return fmt.Errorf("disk %s: %w", disk.Path, ErrMaxSizeExceeded)
+	}
+
+	return nil
+}
+
+// parseStorage understands both flat and sharded layouts: every file is registered in the directory it is found in.
+func (c *Controller) parseStorage(disk *Disk, files map[string]int64) (globalErr error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
 
 	for filename, size := range files {
-		id, err := uuid.Parse(filename)
+		if id, index, ok := fileio.ParseShardName(path.Base(filename)); ok {
+			c.addShard(id, index, shard{disk: disk, path: path.Join(disk.Path, filename)})
+			continue
+		}
+
+		id, err := uuid.Parse(path.Base(filename))
 		if err != nil {
 			errors.Join(globalErr, err)
 			continue
 		}
+		if _, ok := c.Files[id]; ok {
+			continue
+		}
 
-		file, err := fileio.NewFile(path, id, c)
+		acc := &account{Disk: disk}
+		file, err := fileio.NewFile(path.Join(disk.Path, path.Dir(filename)), id, acc)
 		if err != nil {
 			errors.Join(globalErr, err)
 			continue
 		}
+		acc.owner = c.ownerLocked(file.Metadata().OwnerID)
+		acc.owner.add(size)
 
 		c.Files[id] = file
+		c.accounts[id] = acc
+		disk.CurrentSize.Add(size)
 		c.CurrentSize.Add(size)
 	}
 
@@ -0,0 +1,558 @@
+package controller
+
+import (
+	"bytes"
+	"github.com/StratuStore/file-storage/internal/app/fileio"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestController_MigrateLayout_MovesFlatFiles(t *testing.T) {
+	root := t.TempDir()
+	layout, err := NewLayout(2, 2)
+	require.NoError(t, err)
+
+	flatID := uuid.New()
+	require.NoError(t, os.WriteFile(path.Join(root, flatID.String()), []byte("flat"), 0o666))
+
+	c, err := NewController([]*Disk{NewDisk(root, 1024)}, layout, freeSpacePlacement{}, Watermarks{Low: 1, High: 1})
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, c.CurrentSize.Load())
+
+	shardedID := uuid.New()
+	sharded, err := c.AddFile(shardedID)
+	require.NoError(t, err)
+	assert.Equal(t, path.Join(layout.Dir(root, shardedID), shardedID.String()), sharded.FullPath())
+
+	moved, pending, err := c.MigrateLayout()
+	require.NoError(t, err)
+	assert.Equal(t, 1, moved)
+	assert.Equal(t, 0, pending)
+
+	data, err := os.ReadFile(path.Join(layout.Dir(root, flatID), flatID.String()))
+	require.NoError(t, err)
+	assert.Equal(t, "flat", string(data))
+
+	c, err = NewController([]*Disk{NewDisk(root, 1024)}, layout, freeSpacePlacement{}, Watermarks{Low: 1, High: 1})
+	require.NoError(t, err)
+	assert.Len(t, c.Files, 2)
+}
+
+func TestController_Disks_KeepServingAvailableDisks(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	layout, err := NewLayout(0, 0)
+	require.NoError(t, err)
+
+	c, err := NewController([]*Disk{NewDisk(first, 1024), NewDisk(second, 1024), NewDisk(path.Join(first, "missing"), 1024)}, layout, &roundRobinPlacement{}, Watermarks{Low: 1, High: 1})
+	require.NoError(t, err)
+	assert.False(t, c.Disks[2].Available(), "disk without directory must be unavailable")
+	assert.EqualValues(t, 3*1024, c.MaxSize)
+
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+	for _, id := range ids {
+		_, err := c.AddFile(id)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []uuid.UUID{ids[0]}, c.DiskFiles(c.Disks[0]))
+	assert.Equal(t, []uuid.UUID{ids[1]}, c.DiskFiles(c.Disks[1]))
+
+	require.NoError(t, os.Remove(path.Join(second, diskMarker)))
+	assert.Equal(t, []*Disk{c.Disks[1]}, c.CheckDisks())
+
+	_, err = c.File(ids[1])
+	assert.ErrorIs(t, err, ErrDiskUnavailable)
+	_, err = c.File(ids[0])
+	assert.NoError(t, err)
+	assert.NoError(t, c.TryAllocateStorage(1024))
+	assert.ErrorIs(t, c.TryAllocateStorage(1025), ErrMaxSizeExceeded)
+}
+
+type limitedFs struct {
+	osFs
+	free int64
+}
+
+func (fs limitedFs) FreeSpace(string) (int64, error) {
+	return fs.free, nil
+}
+
+func TestController_CheckSpace_SwitchesReadOnlyByWatermarks(t *testing.T) {
+	layout, err := NewLayout(0, 0)
+	require.NoError(t, err)
+	watermarks, err := NewWatermarks(0.5, 0.9)
+	require.NoError(t, err)
+
+	c, err := NewController([]*Disk{NewDisk(t.TempDir(), 1000)}, layout, freeSpacePlacement{}, watermarks)
+	require.NoError(t, err)
+	require.False(t, c.ReadOnly())
+
+	fs := &limitedFs{free: 50}
+	c.FileSystem = fs
+	assert.True(t, c.CheckSpace())
+	assert.True(t, c.ReadOnly())
+	assert.ErrorIs(t, c.TryAllocateStorage(10), ErrReadOnly)
+
+	n, err := c.AllocateAll()
+	require.NoError(t, err)
+	assert.Equal(t, 50, n, "real free space must limit the quota")
+
+	fs.free = 300
+	assert.False(t, c.CheckSpace(), "usage between watermarks must keep the mode")
+	fs.free = 600
+	assert.True(t, c.CheckSpace())
+	assert.NoError(t, c.TryAllocateStorage(10))
+
+	t.Run("released space is free before the next probe", func(t *testing.T) {
+		disk := c.Disks[0]
+		free := disk.Free()
+		require.NoError(t, disk.AllocateStorage(100))
+		assert.Equal(t, free-100, disk.Free())
+		require.NoError(t, disk.ReleaseStorage(100))
+		assert.Equal(t, free, disk.Free())
+	})
+}
+
+func TestController_Reservations_CannotOvercommitDisk(t *testing.T) {
+	layout, err := NewLayout(0, 0)
+	require.NoError(t, err)
+
+	c, err := NewController([]*Disk{NewDisk(t.TempDir(), 100)}, layout, freeSpacePlacement{}, Watermarks{Low: 1, High: 1})
+	require.NoError(t, err)
+
+	file, reservation, err := c.AddReservedFile(uuid.New(), 60, fileio.Metadata{})
+	require.NoError(t, err)
+	assert.EqualValues(t, 60, c.Reserved())
+	assert.EqualValues(t, 40, c.Free())
+
+	_, _, err = c.AddReservedFile(uuid.New(), 60, fileio.Metadata{})
+	assert.ErrorIs(t, err, ErrNoDiskAvailable, "second upload must not fit while the first one is reserved")
+	assert.Len(t, c.Files, 1)
+
+	writer, err := file.Writer(reservation)
+	require.NoError(t, err)
+	_, err = writer.Write(make([]byte, 50))
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, c.Reserved())
+	assert.EqualValues(t, 50, c.CurrentSize.Load())
+
+	require.NoError(t, writer.Close())
+	assert.True(t, reservation.Released())
+	assert.EqualValues(t, 0, c.Reserved())
+	assert.EqualValues(t, 50, c.Free())
+}
+
+func TestController_ReserveFile_TakesContentOver(t *testing.T) {
+	layout, err := NewLayout(0, 0)
+	require.NoError(t, err)
+
+	c, err := NewController([]*Disk{NewDisk(t.TempDir(), 100)}, layout, freeSpacePlacement{}, Watermarks{Low: 1, High: 1})
+	require.NoError(t, err)
+
+	id := uuid.New()
+	file, reservation, err := c.AddReservedFile(id, 60, fileio.Metadata{})
+	require.NoError(t, err)
+	writer, err := file.Writer(reservation)
+	require.NoError(t, err)
+	_, err = writer.Write(make([]byte, 60))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	reservation, err = c.ReserveFile(id, 70)
+	require.NoError(t, err, "rewrite must only need the growth of the file")
+	assert.EqualValues(t, 10, c.Reserved())
+
+	writer, err = file.Writer(reservation)
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, c.CurrentSize.Load())
+	assert.EqualValues(t, 70, c.Reserved(), "released content must be kept for the rewrite")
+	_, _, err = c.AddReservedFile(uuid.New(), 31, fileio.Metadata{})
+	assert.Error(t, err, "released content must not be taken by other uploads")
+
+	_, err = writer.Write(make([]byte, 70))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	assert.EqualValues(t, 70, c.CurrentSize.Load())
+	assert.EqualValues(t, 0, c.Reserved())
+	assert.EqualValues(t, 30, c.Free())
+
+	t.Run("unused reservation leaves the content alone", func(t *testing.T) {
+		reservation, err := c.ReserveFile(id, 70)
+		require.NoError(t, err)
+		reservation.Release()
+		assert.EqualValues(t, 0, c.Reserved())
+
+		require.NoError(t, c.DeleteFile(id))
+		assert.EqualValues(t, 0, c.CurrentSize.Load())
+		assert.EqualValues(t, 0, c.Reserved())
+	})
+}
+
+func TestController_Replace_KeepsContentUnlessVerified(t *testing.T) {
+	layout, err := NewLayout(0, 0)
+	require.NoError(t, err)
+	c, err := NewController([]*Disk{NewDisk(t.TempDir(), 100)}, layout, freeSpacePlacement{}, Watermarks{Low: 1, High: 1})
+	require.NoError(t, err)
+
+	old := bytes.Repeat([]byte("o"), 60)
+	file, reservation, err := c.AddReservedFile(uuid.New(), 60, fileio.Metadata{Replicas: []string{"http://fs-2:5000"}})
+	require.NoError(t, err)
+	writer, err := file.Writer(reservation)
+	require.NoError(t, err)
+	_, err = writer.Write(old)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	require.NoError(t, file.SetMetadata(fileio.Metadata{Replicas: []string{"http://fs-2:5000"}, Checksum: file.Metadata().Checksum}))
+	metadata := file.Metadata()
+
+	content := bytes.Repeat([]byte("n"), 30)
+	checksum, err := fileio.Checksum(bytes.NewReader(content))
+	require.NoError(t, err)
+
+	for name, src := range map[string][]byte{"truncated": content[:10], "damaged": bytes.Repeat([]byte("d"), 30)} {
+		reservation, err := c.ReserveFile(file.ID(), 30)
+		require.NoError(t, err)
+		assert.Error(t, file.Replace(reservation, bytes.NewReader(src), 30, checksum), name)
+
+		data, err := os.ReadFile(file.FullPath())
+		require.NoError(t, err)
+		assert.Equal(t, old, data, name)
+		assert.Equal(t, metadata, file.Metadata(), name)
+		assert.EqualValues(t, 60, c.CurrentSize.Load(), name)
+		assert.EqualValues(t, 0, c.Reserved(), name)
+	}
+
+	reservation, err = c.ReserveFile(file.ID(), 30)
+	require.NoError(t, err)
+	require.NoError(t, file.Replace(reservation, bytes.NewReader(content), 30, checksum))
+	data, err := os.ReadFile(file.FullPath())
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+	assert.Equal(t, checksum, file.Metadata().Checksum)
+	assert.Equal(t, metadata.Replicas, file.Metadata().Replicas, "replicas are kept")
+	assert.EqualValues(t, 30, file.Size())
+	assert.EqualValues(t, 30, c.CurrentSize.Load())
+	assert.EqualValues(t, 0, c.Reserved())
+	_, err = os.Stat(file.FullPath() + ".tmp")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestController_OwnerQuota_CountsReservationsAndSurvivesRestart(t *testing.T) {
+	root := t.TempDir()
+	layout, err := NewLayout(1, 2)
+	require.NoError(t, err)
+
+	c, err := NewController([]*Disk{NewDisk(root, 1000)}, layout, freeSpacePlacement{}, Watermarks{Low: 1, High: 1})
+	require.NoError(t, err)
+	require.NoError(t, c.SetQuota("owner", Quota{MaxBytes: 100, MaxFiles: 2}))
+
+	file, reservation, err := c.AddReservedFile(uuid.New(), 60, fileio.Metadata{OwnerID: "owner"})
+	require.NoError(t, err)
+	_, _, err = c.AddReservedFile(uuid.New(), 60, fileio.Metadata{OwnerID: "owner"})
+	assert.ErrorIs(t, err, ErrQuotaExceeded, "reserved space must count against the quota")
+	_, _, err = c.AddReservedFile(uuid.New(), 60, fileio.Metadata{})
+	assert.NoError(t, err, "files without owner must not be limited")
+
+	writer, err := file.Writer(reservation)
+	require.NoError(t, err)
+	_, err = writer.Write(make([]byte, 60))
+	require.NoError(t, err)
+	_, err = writer.Write(make([]byte, 50))
+	assert.ErrorIs(t, err, ErrQuotaExceeded, "writes beyond the reservation must be limited as well")
+	require.NoError(t, writer.Close())
+
+	_, _, err = c.AddReservedFile(uuid.New(), 40, fileio.Metadata{OwnerID: "owner"})
+	require.NoError(t, err)
+	_, _, err = c.AddReservedFile(uuid.New(), 0, fileio.Metadata{OwnerID: "owner"})
+	assert.ErrorIs(t, err, ErrQuotaExceeded, "file count must be limited")
+
+	c, err = NewController([]*Disk{NewDisk(root, 1000)}, layout, freeSpacePlacement{}, Watermarks{Low: 1, High: 1})
+	require.NoError(t, err)
+	usage, err := c.OwnerUsage("owner")
+	require.NoError(t, err)
+	assert.Equal(t, OwnerUsage{OwnerID: "owner", Used: 60, Files: 2}, usage)
+
+	require.NoError(t, c.DeleteFile(file.ID()))
+	usage, err = c.OwnerUsage("owner")
+	require.NoError(t, err)
+	assert.Equal(t, OwnerUsage{OwnerID: "owner", Files: 1}, usage)
+}
+
+func TestController_MigrateTiers_MovesStaleFilesAndPromotesBack(t *testing.T) {
+	hot, cold := NewDisk(t.TempDir(), 1000), NewDisk(t.TempDir(), 1000)
+	cold.Cold = true
+	layout, err := NewLayout(0, 0)
+	require.NoError(t, err)
+
+	c, err := NewController([]*Disk{hot, cold}, layout, freeSpacePlacement{}, Watermarks{Low: 1, High: 1})
+	require.NoError(t, err)
+
+	stale, reservation, err := c.AddReservedFile(uuid.New(), 4, fileio.Metadata{OwnerID: "owner"})
+	require.NoError(t, err)
+	writer, err := stale.Writer(reservation)
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("cold"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	require.NoError(t, stale.SetMetadata(fileio.Metadata{OwnerID: "owner", AccessedAt: time.Now().Add(-48 * time.Hour)}))
+
+	fresh, err := c.AddFile(uuid.New())
+	require.NoError(t, err)
+
+	moved, pending, err := c.MigrateTiers(24 * time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, moved)
+	assert.Equal(t, 0, pending)
+	assert.Equal(t, []uuid.UUID{stale.ID()}, c.DiskFiles(cold))
+	assert.Equal(t, []uuid.UUID{fresh.ID()}, c.DiskFiles(hot))
+	assert.EqualValues(t, 4, cold.CurrentSize.Load())
+	assert.EqualValues(t, 0, hot.CurrentSize.Load())
+	assert.Equal(t, "owner", stale.Metadata().OwnerID, "metadata must be moved together with the blob")
+
+	data, err := os.ReadFile(stale.FullPath())
+	require.NoError(t, err)
+	assert.Equal(t, "cold", string(data))
+	_, err = os.Stat(path.Join(hot.Path, stale.ID().String()))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, c.Promote(stale.ID()))
+	assert.Len(t, c.DiskFiles(hot), 2)
+	assert.EqualValues(t, 4, hot.CurrentSize.Load())
+	assert.EqualValues(t, 0, cold.CurrentSize.Load())
+}
+
+func TestController_DeleteExpired_FreesSpace(t *testing.T) {
+	root := t.TempDir()
+	layout, err := NewLayout(0, 0)
+	require.NoError(t, err)
+
+	c, err := NewController([]*Disk{NewDisk(root, 100)}, layout, freeSpacePlacement{}, Watermarks{Low: 1, High: 1})
+	require.NoError(t, err)
+
+	expiring, reservation, err := c.AddReservedFile(uuid.New(), 10, fileio.Metadata{ExpiresAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	writer, err := expiring.Writer(reservation)
+	require.NoError(t, err)
+	_, err = writer.Write(make([]byte, 10))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	permanent, err := c.AddFile(uuid.New())
+	require.NoError(t, err)
+
+	deleted, err := c.DeleteExpired(time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+
+	later := time.Now().Add(2 * time.Hour)
+	assert.Equal(t, []uuid.UUID{expiring.ID()}, c.ExpiredFiles(later))
+	deleted, err = c.DeleteExpired(later)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{expiring.ID()}, deleted)
+	assert.EqualValues(t, 0, c.CurrentSize.Load())
+
+	_, err = c.File(expiring.ID())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = c.File(permanent.ID())
+	assert.NoError(t, err)
+	_, err = os.Stat(expiring.FullPath() + ".meta")
+	assert.ErrorIs(t, err, os.ErrNotExist, "metadata sidecar must be deleted as well")
+}
+
+func TestController_ErasureCoding_ReadsDegradedAndRebuilds(t *testing.T) {
+	hot := NewDisk(t.TempDir(), 1<<20)
+	cold := []*Disk{NewDisk(t.TempDir(), 1<<20), NewDisk(t.TempDir(), 1<<20), NewDisk(t.TempDir(), 1<<20), NewDisk(t.TempDir(), 1<<20)}
+	disks := []*Disk{hot}
+	for _, disk := range cold {
+		disk.Cold = true
+		disks = append(disks, disk)
+	}
+	layout, err := NewLayout(0, 0)
+	require.NoError(t, err)
+
+	c, err := NewController(disks, layout, freeSpacePlacement{}, Watermarks{Low: 1, High: 1})
+	require.NoError(t, err)
+	require.ErrorIs(t, c.EnableErasureCoding(ErasureCoding{DataShards: 4, ParityShards: 1}), ErrInvalidErasureCoding)
+	require.NoError(t, c.EnableErasureCoding(ErasureCoding{DataShards: 2, ParityShards: 1}))
+
+	content := make([]byte, 3*fileio.DefaultBlockSize+100)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	file, reservation, err := c.AddReservedFile(uuid.New(), int64(len(content)), fileio.Metadata{OwnerID: "owner"})
+	require.NoError(t, err)
+	writer, err := file.Writer(reservation)
+	require.NoError(t, err)
+	_, err = writer.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	require.NoError(t, file.SetMetadata(fileio.Metadata{OwnerID: "owner", AccessedAt: time.Now().Add(-48 * time.Hour)}))
+
+	moved, _, err := c.MigrateTiers(24 * time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, moved)
+	assert.EqualValues(t, 0, hot.CurrentSize.Load())
+	shardSize := 2 * int64(fileio.DefaultBlockSize)
+	assert.EqualValues(t, 3*shardSize, c.CurrentSize.Load(), "shards must take 1.5x of the content")
+
+	read := func() ([]byte, error) {
+		reader, err := file.Reader(512)
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+
+		return io.ReadAll(reader)
+	}
+	data, err := read()
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+
+	var shards []string
+	for _, disk := range cold {
+		if _, err := os.Stat(path.Join(disk.Path, file.ID().String()+".0")); err == nil {
+			shards = append(shards, path.Join(disk.Path, file.ID().String()+".0"))
+		}
+	}
+	require.Len(t, shards, 1, "every shard must be placed on its own disk")
+	require.NoError(t, os.Remove(shards[0]))
+
+	data, err = read()
+	require.NoError(t, err, "content must be readable without one shard")
+	assert.Equal(t, content, data)
+	assert.Equal(t, []int{0}, file.LostShards(false))
+
+	rebuilt, pending, err := c.RebuildShards(false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, rebuilt)
+	assert.Equal(t, 0, pending)
+	assert.Empty(t, file.LostShards(false))
+
+	t.Run("bit rot", func(t *testing.T) {
+		var shard string
+		for _, disk := range cold {
+			if _, err := os.Stat(path.Join(disk.Path, file.ID().String()+".1")); err == nil {
+				shard = path.Join(disk.Path, file.ID().String()+".1")
+			}
+		}
+		require.NotEmpty(t, shard)
+		rotten, err := os.ReadFile(shard)
+		require.NoError(t, err)
+		rotten[0] ^= 0xff
+		require.NoError(t, os.WriteFile(shard, rotten, 0o666))
+
+		assert.Empty(t, file.LostShards(false), "shards are only hashed when they are verified")
+		assert.Equal(t, []int{1}, file.LostShards(true))
+
+		rebuilt, _, err := c.RebuildShards(true)
+		require.NoError(t, err)
+		assert.Equal(t, 1, rebuilt)
+		assert.Empty(t, file.LostShards(true))
+		data, err := read()
+		require.NoError(t, err)
+		assert.Equal(t, content, data)
+	})
+
+	var truncated string
+	for _, disk := range cold {
+		if _, err := os.Stat(path.Join(disk.Path, file.ID().String()+".2")); err == nil {
+			truncated = path.Join(disk.Path, file.ID().String()+".2")
+		}
+	}
+	require.NotEmpty(t, truncated)
+	require.NoError(t, os.Truncate(truncated, 10))
+
+	c, err = NewController(disks, layout, freeSpacePlacement{}, Watermarks{Low: 1, High: 1})
+	require.NoError(t, err)
+	file, err = c.File(file.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "owner", file.Metadata().OwnerID)
+	usage, err := c.OwnerUsage("owner")
+	require.NoError(t, err)
+	assert.EqualValues(t, len(content), usage.Used)
+	assert.EqualValues(t, 3*shardSize, c.CurrentSize.Load(), "truncated shard is charged with the size it is released with")
+	data, err = read()
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+
+	diskSizes := func() (size int64) {
+		for _, disk := range cold {
+			size += disk.CurrentSize.Load()
+		}
+		return size
+	}
+	before := diskSizes()
+	rebuilt, _, err = c.RebuildShards(false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, rebuilt)
+	assert.EqualValues(t, 3*shardSize, c.CurrentSize.Load())
+	assert.Equal(t, before, diskSizes(), "disks must be charged with what the rebuild releases")
+
+	require.NoError(t, c.Promote(file.ID()))
+	assert.EqualValues(t, len(content), hot.CurrentSize.Load())
+	assert.EqualValues(t, len(content), c.CurrentSize.Load())
+	data, err = os.ReadFile(file.FullPath())
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+}
+
+func TestController_ErasureCoding_UnreadableShardsTakeNoSpace(t *testing.T) {
+	disk := NewDisk(t.TempDir(), 1<<20)
+	require.NoError(t, os.WriteFile(path.Join(disk.Path, fileio.ShardName(uuid.New(), 0)), make([]byte, 100), 0o666))
+	layout, err := NewLayout(0, 0)
+	require.NoError(t, err)
+
+	c, err := NewController([]*Disk{disk}, layout, freeSpacePlacement{}, Watermarks{Low: 1, High: 1})
+	require.NoError(t, err)
+	assert.Empty(t, c.FileIDs())
+	assert.EqualValues(t, 0, disk.CurrentSize.Load())
+	assert.EqualValues(t, 0, c.CurrentSize.Load())
+}
+
+func TestController_Copy_DuplicatesContentAndAccounting(t *testing.T) {
+	disk := NewDisk(t.TempDir(), 1000)
+	layout, err := NewLayout(2, 2)
+	require.NoError(t, err)
+	c, err := NewController([]*Disk{disk}, layout, freeSpacePlacement{}, Watermarks{Low: 1, High: 1})
+	require.NoError(t, err)
+
+	original, reservation, err := c.AddReservedFile(uuid.New(), 8, fileio.Metadata{OwnerID: "owner"})
+	require.NoError(t, err)
+	writer, err := original.Writer(reservation)
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("original"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	for name, checksum := range map[string]string{"known checksum": original.Metadata().Checksum, "unknown checksum": ""} {
+		t.Run(name, func(t *testing.T) {
+			metadata := original.Metadata()
+			metadata.Checksum = checksum
+			require.NoError(t, original.SetMetadata(metadata))
+
+			copied, reservation, err := c.AddReservedFile(uuid.New(), original.Size(), fileio.Metadata{OwnerID: "owner"})
+			require.NoError(t, err)
+			require.NoError(t, fileio.Copy(copied, original, reservation))
+			assert.True(t, reservation.Released())
+
+			data, err := os.ReadFile(copied.FullPath())
+			require.NoError(t, err)
+			assert.Equal(t, "original", string(data))
+			assert.EqualValues(t, 8, copied.Size())
+			expected, err := fileio.Checksum(bytes.NewReader(data))
+			require.NoError(t, err)
+			assert.Equal(t, expected, copied.Metadata().Checksum)
+			assert.Equal(t, "owner", copied.Metadata().OwnerID)
+		})
+	}
+
+	usage, err := c.OwnerUsage("owner")
+	require.NoError(t, err)
+	assert.EqualValues(t, 24, usage.Used)
+	assert.EqualValues(t, 0, usage.Reserved)
+	assert.EqualValues(t, 24, disk.CurrentSize.Load())
+}
@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"errors"
+	"math"
+	"path"
+	"sync"
+	"sync/atomic"
+)
+
+var ErrDiskUnavailable = errors.New("disk is unavailable")
+
+// diskMarker is created in the root of every disk, so an unmounted disk can be told apart from an empty mount point.
+const diskMarker = ".stratustore-disk"
+
+// Disk is a single data directory of the node, it accounts the space of the files placed on it.
+type Disk struct {
+	*Controller
+	Path    string
+	MaxSize int64
+	// Cold disks are not used for new files, they keep files that haven't been accessed for a long time.
+	Cold        bool
+	CurrentSize atomic.Int64
+	// Reserved is the space promised to announced uploads which hasn't been written yet.
+	Reserved atomic.Int64
+	// realFree is the space actually available on the drive as of the last probe minus allocations made since then.
+	realFree    atomic.Int64
+	unavailable atomic.Bool
+	loaded      bool
+	// spaceMx makes checking the free space and taking it a single step.
+	spaceMx sync.Mutex
+}
+
+func NewDisk(path string, maxSize int64) *Disk {
+	disk := &Disk{
+		Path:    path,
+		MaxSize: maxSize,
+	}
+	disk.realFree.Store(math.MaxInt64)
+
+	return disk
+}
+
+func (d *Disk) Available() bool {
+	return !d.unavailable.Load()
+}
+
+// Free returns the number of bytes left on the disk, taking the real free space and reservations into account.
+func (d *Disk) Free() int64 {
+	if !d.Available() {
+		return 0
+	}
+
+	return max(min(d.MaxSize-d.CurrentSize.Load(), d.realFree.Load())-d.Reserved.Load(), 0)
+}
+
+func (d *Disk) AllocateStorage(size int64) error {
+	d.spaceMx.Lock()
+	defer d.spaceMx.Unlock()
+
+	if !d.Available() {
+		return ErrDiskUnavailable
+	}
+	if size > d.Free() {
+		return ErrMaxSizeExceeded
+	}
+	if err := d.Controller.AllocateStorage(size); err != nil {
+		return err
+	}
+
+	d.CurrentSize.Add(size)
+	d.realFree.Add(-size)
+	d.updateMode()
+
+	return nil
+}
+
+// Reserve takes size bytes of the free space for an upload in advance.
+func (d *Disk) Reserve(size int64) (*Reservation, error) {
+	d.spaceMx.Lock()
+	defer d.spaceMx.Unlock()
+
+	if !d.Available() {
+		return nil, ErrDiskUnavailable
+	}
+	if size > d.Free() {
+		return nil, ErrMaxSizeExceeded
+	}
+
+	d.Reserved.Add(size)
+	d.updateMode()
+
+	return &Reservation{disk: d, remaining: size}, nil
+}
+
+// commit turns reserved space into used one.
+func (d *Disk) commit(size int64) {
+	d.Reserved.Add(-size)
+	d.CurrentSize.Add(size)
+	d.realFree.Add(-size)
+	d.Controller.CurrentSize.Add(size)
+}
+
+// hold turns used space into reserved one, so the released content of a rewritten file is kept for its upload.
+func (d *Disk) hold(size int64) {
+	d.spaceMx.Lock()
+	defer d.spaceMx.Unlock()
+
+	d.CurrentSize.Add(-size)
+	d.realFree.Add(size)
+	d.Reserved.Add(size)
+	d.Controller.CurrentSize.Add(-size)
+}
+
+// ReleaseStorage gives the space back to the drive as well, so it is reported free before the next probe.
+func (d *Disk) ReleaseStorage(size int64) error {
+	d.spaceMx.Lock()
+	defer d.spaceMx.Unlock()
+
+	d.CurrentSize.Add(-size)
+	d.realFree.Add(size)
+	err := d.Controller.ReleaseStorage(size)
+	d.updateMode()
+
+	return err
+}
+
+func (d *Disk) AllocateAll() (n int, err error) {
+	n, err = d.Controller.AllocateAll()
+	if err != nil {
+		return 0, err
+	}
+	if free := d.Free(); free > 0 {
+		return min(int(free), n), nil
+	}
+
+	return 0, ErrMaxSizeExceeded
+}
+
+// refreshFree updates the real free space of the disk. Drives that can't report it are limited by the quota only.
+func (d *Disk) refreshFree() error {
+	free, err := d.FreeSpace(d.Path)
+	if err != nil {
+		return err
+	}
+
+	d.realFree.Store(free)
+
+	return nil
+}
+
+// probe checks that the disk is still mounted by looking for its marker.
+func (d *Disk) probe() bool {
+	_, err := d.Stat(path.Join(d.Path, diskMarker))
+
+	return err == nil
+}
+
+func (d *Disk) mark() error {
+	file, err := d.CreateOrOpenForWriting(path.Join(d.Path, diskMarker))
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,268 @@
+package controller
+
+import (
+	"cmp"
+	"errors"
+	"github.com/StratuStore/file-storage/internal/app/fileio"
+	"github.com/google/uuid"
+	"os"
+	"slices"
+)
+
+var ErrInvalidErasureCoding = errors.New("erasure coding needs at least one data and one parity shard and a cold disk per shard")
+
+// ErasureCoding splits cold files into shards on separate cold disks, zero DataShards disables it.
+type ErasureCoding struct {
+	DataShards   int
+	ParityShards int
+}
+
+// shard is a shard blob found on a disk while it is loaded.
+type shard struct {
+	disk *Disk
+	path string
+}
+
+// EnableErasureCoding must be called before the routines are started.
+func (c *Controller) EnableErasureCoding(coding ErasureCoding) error {
+	if coding.DataShards <= 0 || coding.ParityShards <= 0 || len(c.tierDisks(true)) < coding.DataShards+coding.ParityShards {
+		return ErrInvalidErasureCoding
+	}
+	c.erasure = coding
+
+	return nil
+}
+
+func (c *Controller) ErasureCoding() ErasureCoding {
+	return c.erasure
+}
+
+// addShard registers the shard found on a disk. c.mx must be locked.
+func (c *Controller) addShard(id uuid.UUID, index int, s shard) {
+	if _, ok := c.Files[id]; ok {
+		return
+	}
+	if c.shards[id] == nil {
+		c.shards[id] = make(map[int]shard)
+	}
+	c.shards[id][index] = s
+}
+
+// loadShardedFiles registers the erasure coded files once enough of their shards are found to read them.
+func (c *Controller) loadShardedFiles() {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	for id, shards := range c.shards {
+		paths := make(map[int]string, len(shards))
+		var first *Disk
+		for index, s := range shards {
+			paths[index] = s.path
+			if first == nil || index == 0 {
+				first = s.disk
+			}
+		}
+
+		acc := &account{Disk: first}
+		file, err := fileio.NewShardedFile(id, paths, acc)
+		if err != nil {
+			continue
+		}
+		erasure := file.Metadata().Erasure
+		if len(shards) < erasure.DataShards {
+			continue
+		}
+
+		// shards are charged with the size they are released with, whatever their blobs hold
+		acc.shardSize = erasure.ShardSize()
+		acc.shards = make([]*Disk, erasure.Shards())
+		for index, s := range shards {
+			if index < len(acc.shards) {
+				acc.shards[index] = s.disk
+				s.disk.CurrentSize.Add(acc.shardSize)
+				c.CurrentSize.Add(acc.shardSize)
+			}
+		}
+		acc.dataShards = erasure.DataShards
+		acc.owner = c.ownerLocked(file.Metadata().OwnerID)
+		acc.owner.add(erasure.Size)
+
+		c.Files[id] = file
+		c.accounts[id] = acc
+		delete(c.shards, id)
+	}
+}
+
+// encodeFile splits the file into shards placed on distinct disks with the most free space.
+func (c *Controller) encodeFile(id uuid.UUID, disks []*Disk) error {
+	c.mx.RLock()
+	file, ok := c.Files[id]
+	acc := c.accounts[id]
+	c.mx.RUnlock()
+	if !ok {
+		return os.ErrNotExist
+	}
+
+	size := file.Size()
+	erasure, err := fileio.NewErasure(c.erasure.DataShards, c.erasure.ParityShards, size)
+	if err != nil {
+		return err
+	}
+
+	targets, err := pickShardDisks(disks, nil, erasure.Shards(), erasure.ShardSize())
+	if err != nil {
+		return err
+	}
+	for i, disk := range targets {
+		if err := disk.AllocateStorage(erasure.ShardSize()); err != nil {
+			for _, allocated := range targets[:i] {
+				_ = allocated.ReleaseStorage(erasure.ShardSize())
+			}
+			return err
+		}
+	}
+
+	dirs := make([]string, len(targets))
+	for i, disk := range targets {
+		dirs[i] = c.layout.Dir(disk.Path, id)
+	}
+	newAcc := &account{Disk: targets[0], owner: acc.owner, shards: targets, shardSize: erasure.ShardSize(), dataShards: erasure.DataShards}
+	if err := file.Encode(dirs, erasure, newAcc); err != nil {
+		_ = newAcc.releaseDisks(size)
+		return err
+	}
+
+	return c.switchAccount(id, acc, newAcc, size)
+}
+
+// RebuildShards regenerates the lost shards of erasure coded files, and with verify the damaged ones too.
+func (c *Controller) RebuildShards(verify bool) (rebuilt, pending int, err error) {
+	c.mx.RLock()
+	files := make(map[fileio.File]*account)
+	for id, file := range c.Files {
+		if acc := c.accounts[id]; acc.sharded() {
+			files[file] = acc
+		}
+	}
+	c.mx.RUnlock()
+
+	for file, acc := range files {
+		n, err2 := c.rebuildFile(file, acc, verify)
+		switch {
+		case errors.Is(err2, fileio.ErrBusy):
+			pending++
+		case errors.Is(err2, os.ErrClosed):
+			// file has been deleted in the meantime
+		case err2 != nil:
+			err = errors.Join(err, err2)
+		}
+		rebuilt += n
+	}
+
+	return rebuilt, pending, err
+}
+
+func (c *Controller) rebuildFile(file fileio.File, acc *account, verify bool) (int, error) {
+	acc.shardsMx.RLock()
+	shards := slices.Clone(acc.shards)
+	acc.shardsMx.RUnlock()
+	if shards == nil {
+		return 0, nil
+	}
+
+	lost := make(map[int]bool)
+	for _, i := range file.LostShards(verify) {
+		lost[i] = true
+	}
+	var used []*Disk
+	for i, disk := range shards {
+		if disk == nil || !disk.Available() {
+			lost[i] = true
+		} else if !lost[i] {
+			used = append(used, disk)
+		}
+	}
+	if len(lost) == 0 {
+		return 0, nil
+	}
+
+	// lost shards are rebuilt in place when their disks are available, otherwise on other cold disks
+	targets := make(map[int]*Disk, len(lost))
+	for i := range lost {
+		if disk := shards[i]; disk != nil && disk.Available() && !slices.Contains(used, disk) {
+			targets[i] = disk
+		} else {
+			picked, err := pickShardDisks(c.tierDisks(true), used, 1, acc.shardSize)
+			if err != nil {
+				return 0, err
+			}
+			targets[i] = picked[0]
+		}
+		used = append(used, targets[i])
+	}
+
+	var allocated []*Disk
+	release := func() {
+		for _, disk := range allocated {
+			_ = disk.ReleaseStorage(acc.shardSize)
+		}
+	}
+	dirs := make(map[int]string, len(targets))
+	for i, disk := range targets {
+		if err := disk.AllocateStorage(acc.shardSize); err != nil {
+			release()
+			return 0, err
+		}
+		allocated = append(allocated, disk)
+		dirs[i] = c.layout.Dir(disk.Path, file.ID())
+	}
+
+	newShards := slices.Clone(shards)
+	for i, disk := range targets {
+		newShards[i] = disk
+	}
+	newAcc := &account{Disk: newShards[0], owner: acc.owner, shards: newShards, shardSize: acc.shardSize, dataShards: acc.dataShards}
+	if err := file.RebuildShards(dirs, newAcc); err != nil {
+		release()
+		return 0, err
+	}
+
+	c.mx.Lock()
+	current, ok := c.accounts[file.ID()]
+	if ok && current == acc {
+		c.accounts[file.ID()] = newAcc
+	}
+	c.mx.Unlock()
+	if !ok || current != acc {
+		release()
+		return 0, os.ErrClosed
+	}
+
+	// the space of the replaced shards is given back to their disks
+	for i := range targets {
+		if disk := shards[i]; disk != nil {
+			_ = disk.ReleaseStorage(acc.shardSize)
+		}
+	}
+
+	return len(targets), nil
+}
+
+// pickShardDisks returns n available disks with the most free space, skipping the used ones.
+func pickShardDisks(disks, used []*Disk, n int, shardSize int64) ([]*Disk, error) {
+	var candidates []*Disk
+	for _, disk := range disks {
+		if disk.Available() && !slices.Contains(used, disk) && disk.Free() >= shardSize {
+			candidates = append(candidates, disk)
+		}
+	}
+	if len(candidates) < n {
+		return nil, ErrNoDiskAvailable
+	}
+
+	slices.SortStableFunc(candidates, func(a, b *Disk) int {
+		return cmp.Compare(b.Free(), a.Free())
+	})
+
+	return candidates[:n], nil
+}
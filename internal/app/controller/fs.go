@@ -4,14 +4,20 @@ import (
 	"errors"
 	"github.com/StratuStore/file-storage/internal/app/fileio"
 	"os"
+	"path"
 )
 
 type FileSystem interface {
 	OpenForReading(name string) (fileio.FsFile, error)
 	Stat(name string) (os.FileInfo, error)
 	FSDelete(name string) error
+	FSRename(oldName, newName string) error
 	CreateOrOpenForWriting(name string) (fileio.FsFile, error)
-	ListDir(path string) (files map[string]int64, err error)
+	// ListDir returns sizes of all files keyed by their path relative to root.
+	// Nested directories are visited up to the given depth.
+	ListDir(root string, depth int) (files map[string]int64, err error)
+	// FreeSpace returns the number of bytes actually available on the drive the path belongs to.
+	FreeSpace(path string) (int64, error)
 }
 
 // osFs implements fileSystem using the local drive.
@@ -25,21 +31,43 @@ func (osFs) FSDelete(name string) error {
 	return os.Remove(name)
 }
 
+func (osFs) FSRename(oldName, newName string) error {
+	if err := os.MkdirAll(path.Dir(newName), 0o777); err != nil {
+		return err
+	}
+
+	return os.Rename(oldName, newName)
+}
+
 func (osFs) CreateOrOpenForWriting(name string) (fileio.FsFile, error) {
+	if err := os.MkdirAll(path.Dir(name), 0o777); err != nil {
+		return nil, err
+	}
+
 	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE, 0o666)
 
 	return file, err
 }
 
-func (osFs) ListDir(path string) (files map[string]int64, err error) {
+func (osFs) ListDir(root string, depth int) (files map[string]int64, err error) {
 	files = make(map[string]int64)
-	dir, err := os.ReadDir(path)
+
+	return files, listDir(root, "", depth, files)
+}
+
+func listDir(root, rel string, depth int, files map[string]int64) (err error) {
+	dir, err := os.ReadDir(path.Join(root, rel))
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	for _, file := range dir {
+		name := path.Join(rel, file.Name())
+
 		if file.IsDir() {
+			if depth > 0 {
+				err = errors.Join(err, listDir(root, name, depth-1, files))
+			}
 			continue
 		}
 
@@ -49,8 +77,8 @@ func (osFs) ListDir(path string) (files map[string]int64, err error) {
 			continue
 		}
 
-		files[stat.Name()] = stat.Size()
+		files[name] = stat.Size()
 	}
 
-	return files, err
+	return err
 }
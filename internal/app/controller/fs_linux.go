@@ -0,0 +1,14 @@
+//go:build linux
+
+package controller
+
+import "syscall"
+
+func (osFs) FreeSpace(path string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+
+	return int64(stat.Bavail) * stat.Bsize, nil
+}
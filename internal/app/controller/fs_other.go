@@ -0,0 +1,10 @@
+//go:build !linux
+
+package controller
+
+import "errors"
+
+// FreeSpace isn't supported outside of linux, so only the configured quota is taken into account.
+func (osFs) FreeSpace(path string) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"path"
+	"strings"
+)
+
+var ErrInvalidLayout = errors.New("invalid storage layout")
+
+// Layout spreads blobs across nested directories, e.g. Depth 2 and Width 2 place a blob into "ab/cd/<uuid>".
+type Layout struct {
+	Depth int
+	Width int
+}
+
+func NewLayout(depth, width int) (Layout, error) {
+	if depth < 0 || (depth > 0 && width <= 0) || depth*width > 32 {
+		return Layout{}, ErrInvalidLayout
+	}
+
+	return Layout{Depth: depth, Width: width}, nil
+}
+
+// Dir returns the directory the blob with the given id belongs to.
+func (l Layout) Dir(root string, id uuid.UUID) string {
+	name := strings.ReplaceAll(id.String(), "-", "")
+
+	parts := make([]string, 0, l.Depth+1)
+	parts = append(parts, root)
+	for i := range l.Depth {
+		parts = append(parts, name[i*l.Width:(i+1)*l.Width])
+	}
+
+	return path.Join(parts...)
+}
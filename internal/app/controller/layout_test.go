@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestLayout_Dir(t *testing.T) {
+	id := uuid.MustParse("abcdef01-2345-6789-abcd-ef0123456789")
+
+	testData := []struct {
+		depth, width int
+		expected     string
+	}{
+		{0, 2, "/storage"},
+		{1, 2, "/storage/ab"},
+		{2, 2, "/storage/ab/cd"},
+		{2, 3, "/storage/abc/def"},
+	}
+
+	for _, tt := range testData {
+		layout, err := NewLayout(tt.depth, tt.width)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, layout.Dir("/storage", id))
+	}
+
+	_, err := NewLayout(3, 11)
+	assert.ErrorIs(t, err, ErrInvalidLayout)
+}
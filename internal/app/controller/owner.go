@@ -0,0 +1,249 @@
+package controller
+
+import (
+	"errors"
+	"sync"
+)
+
+var ErrQuotaExceeded = errors.New("owner quota exceeded")
+
+// Quota limits the space and the number of files of an owner on this node. Zero stands for no limit.
+type Quota struct {
+	MaxBytes int64
+	MaxFiles int64
+}
+
+type OwnerUsage struct {
+	OwnerID  string
+	Used     int64
+	Reserved int64
+	Files    int64
+	Quota    Quota
+}
+
+// Owner tracks the usage of a tenant and enforces its quota, a nil Owner stands for files without an owner.
+type Owner struct {
+	id       string
+	quota    Quota
+	used     int64
+	reserved int64
+	files    int64
+	mx       sync.Mutex
+}
+
+func (o *Owner) Usage() OwnerUsage {
+	o.mx.Lock()
+	defer o.mx.Unlock()
+
+	return OwnerUsage{
+		OwnerID:  o.id,
+		Used:     o.used,
+		Reserved: o.reserved,
+		Files:    o.files,
+		Quota:    o.quota,
+	}
+}
+
+func (o *Owner) setQuota(quota Quota) {
+	o.mx.Lock()
+	defer o.mx.Unlock()
+
+	o.quota = quota
+}
+
+// reserve takes size bytes of the quota in advance, and a slot for a new file when newFile is set.
+func (o *Owner) reserve(size int64, newFile bool) error {
+	if o == nil {
+		return nil
+	}
+
+	o.mx.Lock()
+	defer o.mx.Unlock()
+
+	if o.quota.MaxBytes > 0 && o.used+o.reserved+size > o.quota.MaxBytes {
+		return ErrQuotaExceeded
+	}
+	if newFile && o.quota.MaxFiles > 0 && o.files+1 > o.quota.MaxFiles {
+		return ErrQuotaExceeded
+	}
+
+	o.reserved += size
+	if newFile {
+		o.files++
+	}
+
+	return nil
+}
+
+func (o *Owner) unreserve(size int64) {
+	if o == nil {
+		return
+	}
+
+	o.mx.Lock()
+	defer o.mx.Unlock()
+
+	o.reserved -= size
+}
+
+func (o *Owner) commit(size int64) {
+	if o == nil {
+		return
+	}
+
+	o.mx.Lock()
+	defer o.mx.Unlock()
+
+	o.reserved -= size
+	o.used += size
+}
+
+// hold is the reverse of commit.
+func (o *Owner) hold(size int64) {
+	if o == nil {
+		return
+	}
+
+	o.mx.Lock()
+	defer o.mx.Unlock()
+
+	o.used -= size
+	o.reserved += size
+}
+
+func (o *Owner) allocate(size int64) error {
+	if o == nil {
+		return nil
+	}
+
+	o.mx.Lock()
+	defer o.mx.Unlock()
+
+	if o.quota.MaxBytes > 0 && o.used+o.reserved+size > o.quota.MaxBytes {
+		return ErrQuotaExceeded
+	}
+	o.used += size
+
+	return nil
+}
+
+func (o *Owner) release(size int64) {
+	if o == nil {
+		return
+	}
+
+	o.mx.Lock()
+	defer o.mx.Unlock()
+
+	o.used -= size
+}
+
+// add registers a file found on the drive, it is never limited by the quota.
+func (o *Owner) add(size int64) {
+	if o == nil {
+		return
+	}
+
+	o.mx.Lock()
+	defer o.mx.Unlock()
+
+	o.used += size
+	o.files++
+}
+
+func (o *Owner) remove(size int64) {
+	if o == nil {
+		return
+	}
+
+	o.mx.Lock()
+	defer o.mx.Unlock()
+
+	o.used -= size
+	o.files--
+}
+
+// account is the fileio.StorageController of a file, charging both its disk and its owner.
+type account struct {
+	*Disk
+	owner *Owner
+	// shards are the disks of the shards of an erasure coded file, Disk is the one of the first shard then
+	shards     []*Disk
+	shardSize  int64
+	dataShards int
+	shardsMx   sync.RWMutex
+	// rewrite is the reservation of the upload rewriting the file, it takes the content over once it is released
+	rewrite   *Reservation
+	rewriteMx sync.Mutex
+}
+
+// Available reports whether an erasure coded file still has enough shards on available disks to be read.
+func (a *account) Available() bool {
+	a.shardsMx.RLock()
+	defer a.shardsMx.RUnlock()
+	if a.shards == nil {
+		return a.Disk.Available()
+	}
+
+	var available int
+	for _, disk := range a.shards {
+		if disk != nil && disk.Available() {
+			available++
+		}
+	}
+
+	return available >= a.dataShards
+}
+
+func (a *account) sharded() bool {
+	a.shardsMx.RLock()
+	defer a.shardsMx.RUnlock()
+
+	return a.shards != nil
+}
+
+// releaseDisks gives back the space of the file to its disks.
+func (a *account) releaseDisks(size int64) error {
+	a.shardsMx.Lock()
+	defer a.shardsMx.Unlock()
+	if a.shards == nil {
+		return a.Disk.ReleaseStorage(size)
+	}
+
+	var err error
+	for _, disk := range a.shards {
+		if disk != nil {
+			err = errors.Join(err, disk.ReleaseStorage(a.shardSize))
+		}
+	}
+	a.shards = nil
+
+	return err
+}
+
+func (a *account) AllocateStorage(size int64) error {
+	if err := a.owner.allocate(size); err != nil {
+		return err
+	}
+	if err := a.Disk.AllocateStorage(size); err != nil {
+		a.owner.release(size)
+		return err
+	}
+
+	return nil
+}
+
+func (a *account) ReleaseStorage(size int64) error {
+	a.rewriteMx.Lock()
+	r := a.rewrite
+	a.rewrite = nil
+	a.rewriteMx.Unlock()
+	if r != nil && r.disk == a.Disk && !a.sharded() {
+		r.hold(size)
+		return nil
+	}
+
+	a.owner.release(size)
+
+	return a.releaseDisks(size)
+}
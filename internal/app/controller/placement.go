@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+var ErrNoDiskAvailable = errors.New("no disk available")
+
+const (
+	FreeSpacePlacement  = "free-space"
+	RoundRobinPlacement = "round-robin"
+)
+
+// Placement chooses the disk a new file of the given size is created on.
+type Placement interface {
+	Pick(disks []*Disk, size int64) (*Disk, error)
+}
+
+func NewPlacement(name string) (Placement, error) {
+	switch name {
+	case FreeSpacePlacement:
+		return freeSpacePlacement{}, nil
+	case RoundRobinPlacement:
+		return &roundRobinPlacement{}, nil
+	default:
+		return nil, fmt.Errorf("unknown placement %q", name)
+	}
+}
+
+// freeSpacePlacement picks the disk with the most free space.
+type freeSpacePlacement struct{}
+
+func (freeSpacePlacement) Pick(disks []*Disk, size int64) (*Disk, error) {
+	var best *Disk
+	for _, disk := range disks {
+		if disk.Free() > 0 && disk.Free() >= size && (best == nil || disk.Free() > best.Free()) {
+			best = disk
+		}
+	}
+
+	if best == nil {
+		return nil, ErrNoDiskAvailable
+	}
+
+	return best, nil
+}
+
+// roundRobinPlacement cycles through the disks that fit the file.
+type roundRobinPlacement struct {
+	next atomic.Uint64
+}
+
+func (p *roundRobinPlacement) Pick(disks []*Disk, size int64) (*Disk, error) {
+	for range disks {
+		disk := disks[(p.next.Add(1)-1)%uint64(len(disks))]
+		if disk.Free() > 0 && disk.Free() >= size {
+			return disk, nil
+		}
+	}
+
+	return nil, ErrNoDiskAvailable
+}
@@ -0,0 +1,91 @@
+package controller
+
+import "sync"
+
+// Reservation is space reserved on a disk for an announced upload.
+type Reservation struct {
+	disk  *Disk
+	owner *Owner
+	// acc is the account of the rewritten file whose content the reservation is going to take over
+	acc       *account
+	remaining int64
+	released  bool
+	mx        sync.Mutex
+}
+
+func (r *Reservation) Consume(size int64) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	reserved := min(size, r.remaining)
+	if excess := size - reserved; excess > 0 {
+		if err := r.owner.allocate(excess); err != nil {
+			return err
+		}
+		if err := r.disk.AllocateStorage(excess); err != nil {
+			r.owner.release(excess)
+			return err
+		}
+	}
+
+	r.remaining -= reserved
+	r.disk.commit(reserved)
+	r.owner.commit(reserved)
+
+	return nil
+}
+
+// Refund gives back storage consumed by content which has been discarded, e.g. a temporary blob.
+func (r *Reservation) Refund(size int64) {
+	r.owner.release(size)
+	_ = r.disk.ReleaseStorage(size)
+}
+
+// hold adds the released content of the rewritten file to the reservation, or gives it back if it's released already.
+func (r *Reservation) hold(size int64) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if r.released {
+		r.owner.release(size)
+		_ = r.disk.ReleaseStorage(size)
+		return
+	}
+
+	r.remaining += size
+	r.disk.hold(size)
+	r.owner.hold(size)
+}
+
+func (r *Reservation) Release() {
+	if r.acc != nil {
+		r.acc.rewriteMx.Lock()
+		if r.acc.rewrite == r {
+			r.acc.rewrite = nil
+		}
+		r.acc.rewriteMx.Unlock()
+	}
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	r.disk.Reserved.Add(-r.remaining)
+	r.owner.unreserve(r.remaining)
+	r.remaining = 0
+	r.released = true
+}
+
+func (r *Reservation) Released() bool {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	return r.released
+}
+
+// Remaining returns the reserved space which hasn't been consumed yet.
+func (r *Reservation) Remaining() int64 {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	return r.remaining
+}
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"errors"
+	"github.com/StratuStore/file-storage/internal/app/fileio"
+	"github.com/google/uuid"
+	"os"
+	"time"
+)
+
+// tierDisks returns the disks of the tier, every disk is hot if all of them are cold.
+func (c *Controller) tierDisks(cold bool) []*Disk {
+	var disks []*Disk
+	for _, disk := range c.Disks {
+		if disk.Cold == cold {
+			disks = append(disks, disk)
+		}
+	}
+
+	if !cold && len(disks) == 0 {
+		return c.Disks
+	}
+
+	return disks
+}
+
+// HasColdTier reports whether some disks are marked as cold.
+func (c *Controller) HasColdTier() bool {
+	for _, disk := range c.Disks {
+		if disk.Cold {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MigrateTiers moves or encodes files which haven't been accessed for coldAfter to the cold tier.
+func (c *Controller) MigrateTiers(coldAfter time.Duration) (moved, pending int, err error) {
+	cold := c.tierDisks(true)
+	if len(cold) == 0 {
+		return 0, 0, nil
+	}
+
+	c.mx.RLock()
+	files := make(map[fileio.File]*account, len(c.Files))
+	for id, file := range c.Files {
+		files[file] = c.accounts[id]
+	}
+	c.mx.RUnlock()
+
+	threshold := time.Now().Add(-coldAfter)
+	for file, acc := range files {
+		// with erasure coding enabled, whole blobs rewritten on the cold tier are encoded as well
+		encode := c.erasure.DataShards > 0 && !acc.sharded()
+		if (acc.Cold && !encode) || !acc.Available() {
+			continue
+		}
+		if file.AccessedAt().After(threshold) {
+			if err2 := file.FlushAccessTime(); err2 != nil && !errors.Is(err2, os.ErrClosed) {
+				err = errors.Join(err, err2)
+			}
+			continue
+		}
+
+		move := c.moveFile
+		if encode {
+			move = c.encodeFile
+		}
+
+		switch err2 := move(file.ID(), cold); {
+		case errors.Is(err2, fileio.ErrBusy):
+			pending++
+		case errors.Is(err2, os.ErrClosed), errors.Is(err2, os.ErrNotExist):
+			// file has been deleted in the meantime
+		case err2 != nil:
+			err = errors.Join(err, err2)
+		default:
+			moved++
+		}
+	}
+
+	return moved, pending, err
+}
+
+// Promote moves the file back to the hot tier, hot files are left untouched.
+func (c *Controller) Promote(id uuid.UUID) error {
+	c.mx.RLock()
+	acc, ok := c.accounts[id]
+	c.mx.RUnlock()
+	if !ok {
+		return os.ErrNotExist
+	}
+	if !acc.Cold {
+		return nil
+	}
+
+	return c.moveFile(id, c.tierDisks(false))
+}
+
+// moveFile copies the file to one of the disks and switches its accounting to the new disk.
+func (c *Controller) moveFile(id uuid.UUID, disks []*Disk) error {
+	c.mx.RLock()
+	file, ok := c.Files[id]
+	acc := c.accounts[id]
+	c.mx.RUnlock()
+	if !ok {
+		return os.ErrNotExist
+	}
+
+	size := file.Size()
+	target, err := c.placement.Pick(disks, size)
+	if err != nil {
+		return err
+	}
+	if err := target.AllocateStorage(size); err != nil {
+		return err
+	}
+
+	newAcc := &account{Disk: target, owner: acc.owner}
+	if err := file.MoveTo(c.layout.Dir(target.Path, id), newAcc); err != nil {
+		_ = target.ReleaseStorage(size)
+		return err
+	}
+
+	return c.switchAccount(id, acc, newAcc, size)
+}
+
+// switchAccount replaces the account of the file moved to other disks and releases the space on the old ones.
+func (c *Controller) switchAccount(id uuid.UUID, acc, newAcc *account, size int64) error {
+	c.mx.Lock()
+	current, ok := c.accounts[id]
+	if ok && current == acc {
+		c.accounts[id] = newAcc
+	}
+	c.mx.Unlock()
+
+	// the file has been deleted right after the move and its space was given back to the old disk
+	if !ok || current != acc {
+		_ = newAcc.releaseDisks(size)
+		return os.ErrClosed
+	}
+
+	return acc.releaseDisks(size)
+}
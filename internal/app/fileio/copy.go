@@ -0,0 +1,48 @@
+package fileio
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// Copy replaces the content of dst by the one of src and releases the reservation in any case.
+func Copy(dst, src File, reservation Reservation) error {
+	release := func() {
+		if reservation != nil {
+			reservation.Release()
+		}
+	}
+
+	if src.Closed() {
+		release()
+		return os.ErrClosed
+	}
+	if !src.rwMx().TryRLock() {
+		release()
+		return ErrBusy
+	}
+	defer src.rwMx().RUnlock()
+
+	from, err := src.openForReading()
+	if err != nil {
+		release()
+		return err
+	}
+	defer from.Close()
+
+	to, err := dst.Writer(reservation)
+	if err != nil {
+		release()
+		return err
+	}
+
+	checksum := src.Metadata().Checksum
+	if w, ok := to.(*writer); ok && checksum != "" {
+		_, err = w.copyFrom(from, src.Size(), checksum)
+	} else {
+		_, err = io.Copy(to, from)
+	}
+
+	return errors.Join(err, to.Close())
+}
@@ -0,0 +1,383 @@
+package fileio
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/klauspost/reedsolomon"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBlockSize is the size of the block every shard contributes to a stripe.
+const DefaultBlockSize = 64 << 10
+
+var (
+	ErrTooFewShards  = errors.New("too few shards are readable")
+	ErrErasureCoded  = errors.New("erasure coded blob is read-only")
+	errInvalidShards = errors.New("erasure coding needs at least one data and one parity shard")
+)
+
+// Erasure describes how the content of an erasure coded file is split into shards.
+type Erasure struct {
+	DataShards   int   `json:"dataShards"`
+	ParityShards int   `json:"parityShards"`
+	BlockSize    int64 `json:"blockSize"`
+	// Size is the size of the content, the last stripe is padded with zeroes.
+	Size int64 `json:"size"`
+	// Checksums are the hex encoded SHA-256 of the shards, files encoded before they were kept have none.
+	Checksums []string `json:"checksums,omitempty"`
+}
+
+func NewErasure(dataShards, parityShards int, size int64) (Erasure, error) {
+	if dataShards <= 0 || parityShards <= 0 {
+		return Erasure{}, errInvalidShards
+	}
+
+	return Erasure{DataShards: dataShards, ParityShards: parityShards, BlockSize: DefaultBlockSize, Size: size}, nil
+}
+
+func (e Erasure) Shards() int {
+	return e.DataShards + e.ParityShards
+}
+
+func (e Erasure) ShardSize() int64 {
+	return e.stripes() * e.BlockSize
+}
+
+func (e Erasure) stripes() int64 {
+	stripeSize := e.BlockSize * int64(e.DataShards)
+
+	return (e.Size + stripeSize - 1) / stripeSize
+}
+
+// ShardName returns the name of the blob holding the shard of the file.
+func ShardName(id uuid.UUID, index int) string {
+	return fmt.Sprintf("%s.%d", id, index)
+}
+
+// ParseShardName is the reverse of ShardName.
+func ParseShardName(name string) (id uuid.UUID, index int, ok bool) {
+	rawID, rawIndex, found := strings.Cut(name, ".")
+	if !found {
+		return id, 0, false
+	}
+
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return id, 0, false
+	}
+	index, err = strconv.Atoi(rawIndex)
+	if err != nil || index < 0 {
+		return id, 0, false
+	}
+
+	return id, index, true
+}
+
+// verify reports whether the shard matches its checksum, shards without a checksum are trusted.
+func (e Erasure) verify(index int, shard io.ReaderAt) bool {
+	if index >= len(e.Checksums) {
+		return true
+	}
+	checksum, err := Checksum(io.NewSectionReader(shard, 0, e.ShardSize()))
+
+	return err == nil && checksum == e.Checksums[index]
+}
+
+// hashingWriters wraps the writers, so the checksums of what has been written to them can be returned.
+func hashingWriters(dst []io.Writer) ([]io.Writer, func() []string) {
+	hashes := make([]hash.Hash, len(dst))
+	writers := make([]io.Writer, len(dst))
+	for i, w := range dst {
+		hashes[i] = sha256.New()
+		writers[i] = io.MultiWriter(w, hashes[i])
+	}
+
+	return writers, func() []string {
+		checksums := make([]string, len(hashes))
+		for i, h := range hashes {
+			checksums[i] = hex.EncodeToString(h.Sum(nil))
+		}
+		return checksums
+	}
+}
+
+// encodeShards splits the content read from src into the shards written to dst, and returns their checksums.
+func encodeShards(src io.Reader, dst []io.Writer, e Erasure) ([]string, error) {
+	encoder, err := reedsolomon.New(e.DataShards, e.ParityShards)
+	if err != nil {
+		return nil, err
+	}
+	dst, checksums := hashingWriters(dst)
+
+	src = io.LimitReader(src, e.Size)
+	blocks := make([][]byte, e.Shards())
+	for i := range blocks {
+		blocks[i] = make([]byte, e.BlockSize)
+	}
+
+	for range e.stripes() {
+		for i := range e.DataShards {
+			clear(blocks[i])
+			if _, err := io.ReadFull(src, blocks[i]); err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil, err
+			}
+		}
+		if err := encoder.Encode(blocks); err != nil {
+			return nil, err
+		}
+
+		for i, w := range dst {
+			if _, err := w.Write(blocks[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return checksums(), nil
+}
+
+// shardedBlob reads the content of an erasure coded file, reconstructing the stripes of missing shards.
+type shardedBlob struct {
+	erasure Erasure
+	encoder reedsolomon.Encoder
+	// shards are nil once they are lost
+	shards []FsFile
+	pos    int64
+	// blocks keep the last reconstructed stripe
+	stripe int64
+	blocks [][]byte
+}
+
+func openShardedBlob(fs FileSystem, paths []string, e Erasure) (*shardedBlob, error) {
+	encoder, err := reedsolomon.New(e.DataShards, e.ParityShards)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &shardedBlob{
+		erasure: e,
+		encoder: encoder,
+		shards:  make([]FsFile, len(paths)),
+		stripe:  -1,
+	}
+
+	var available int
+	for i, name := range paths {
+		if name == "" {
+			continue
+		}
+		if f, err := fs.OpenForReading(name); err == nil {
+			b.shards[i] = f
+			available++
+		}
+	}
+	if available < e.DataShards {
+		b.Close()
+		return nil, ErrTooFewShards
+	}
+
+	return b, nil
+}
+
+func (b *shardedBlob) lose(index int) {
+	b.shards[index].Close()
+	b.shards[index] = nil
+}
+
+func (b *shardedBlob) Read(p []byte) (n int, err error) {
+	n, err = b.ReadAt(p, b.pos)
+	b.pos += int64(n)
+
+	return n, err
+}
+
+func (b *shardedBlob) ReadAt(p []byte, off int64) (n int, err error) {
+	e := b.erasure
+	stripeSize := e.BlockSize * int64(e.DataShards)
+
+	for n < len(p) && off < e.Size {
+		stripe := off / stripeSize
+		index := int(off % stripeSize / e.BlockSize)
+		inner := off % e.BlockSize
+		size := min(int64(len(p)-n), e.BlockSize-inner, e.Size-off)
+
+		read, err := b.readBlock(stripe, index, inner, p[n:int64(n)+size])
+		n += read
+		off += int64(read)
+		if err != nil {
+			return n, err
+		}
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+func (b *shardedBlob) readBlock(stripe int64, index int, inner int64, p []byte) (int, error) {
+	if b.stripe != stripe {
+		if f := b.shards[index]; f != nil {
+			if n, _ := f.ReadAt(p, stripe*b.erasure.BlockSize+inner); n == len(p) {
+				return n, nil
+			}
+
+			// the shard is damaged, so it is reconstructed from now on
+			b.lose(index)
+		}
+	}
+
+	if err := b.reconstruct(stripe); err != nil {
+		return 0, err
+	}
+
+	return copy(p, b.blocks[index][inner:]), nil
+}
+
+// reconstruct restores the data blocks of the stripe from any DataShards readable shards.
+func (b *shardedBlob) reconstruct(stripe int64) error {
+	if b.stripe == stripe {
+		return nil
+	}
+
+	blocks, err := b.readStripe(stripe, nil)
+	if err != nil {
+		return err
+	}
+	if err := b.encoder.ReconstructData(blocks); err != nil {
+		return err
+	}
+	b.blocks, b.stripe = blocks, stripe
+
+	return nil
+}
+
+// readStripe reads DataShards blocks of the stripe, skipping the given shards. Unread blocks are nil.
+func (b *shardedBlob) readStripe(stripe int64, skip map[int]bool) ([][]byte, error) {
+	blocks := make([][]byte, len(b.shards))
+
+	var read int
+	for i := range b.shards {
+		if skip[i] || read == b.erasure.DataShards {
+			continue
+		}
+		f := b.shards[i]
+		if f == nil {
+			continue
+		}
+
+		block := make([]byte, b.erasure.BlockSize)
+		if n, _ := f.ReadAt(block, stripe*b.erasure.BlockSize); n != len(block) {
+			b.lose(i)
+			continue
+		}
+		blocks[i] = block
+		read++
+	}
+	if read < b.erasure.DataShards {
+		return nil, ErrTooFewShards
+	}
+
+	return blocks, nil
+}
+
+// rebuild regenerates the shards written to dst from the other shards, and checks that they match their checksums.
+func (b *shardedBlob) rebuild(dst map[int]io.Writer) error {
+	skip := make(map[int]bool, len(dst))
+	indexes := make([]int, 0, len(dst))
+	writers := make([]io.Writer, 0, len(dst))
+	for i, w := range dst {
+		skip[i] = true
+		indexes = append(indexes, i)
+		writers = append(writers, w)
+	}
+	writers, checksums := hashingWriters(writers)
+
+	for stripe := range b.erasure.stripes() {
+		blocks, err := b.readStripe(stripe, skip)
+		if err != nil {
+			return err
+		}
+		if err := b.encoder.Reconstruct(blocks); err != nil {
+			return err
+		}
+
+		for j, w := range writers {
+			if _, err := w.Write(blocks[indexes[j]]); err != nil {
+				return err
+			}
+		}
+	}
+
+	for j, checksum := range checksums() {
+		if i := indexes[j]; i < len(b.erasure.Checksums) && checksum != b.erasure.Checksums[i] {
+			return fmt.Errorf("%w: rebuilt shard %d", ErrChecksumMismatch, i)
+		}
+	}
+
+	return nil
+}
+
+func (b *shardedBlob) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += b.pos
+	case io.SeekEnd:
+		offset += b.erasure.Size
+	}
+	if offset < 0 {
+		return 0, fs.ErrInvalid
+	}
+	b.pos = offset
+
+	return offset, nil
+}
+
+func (b *shardedBlob) Stat() (os.FileInfo, error) {
+	return shardedInfo{size: b.erasure.Size}, nil
+}
+
+func (b *shardedBlob) Write([]byte) (int, error) {
+	return 0, ErrErasureCoded
+}
+
+func (b *shardedBlob) Truncate(int64) error {
+	return ErrErasureCoded
+}
+
+func (b *shardedBlob) Sync() error {
+	return nil
+}
+
+func (b *shardedBlob) Close() error {
+	var err error
+	for i, f := range b.shards {
+		if f != nil {
+			err = errors.Join(err, f.Close())
+			b.shards[i] = nil
+		}
+	}
+
+	return err
+}
+
+type shardedInfo struct {
+	size int64
+}
+
+func (i shardedInfo) Name() string       { return "" }
+func (i shardedInfo) Size() int64        { return i.size }
+func (i shardedInfo) Mode() fs.FileMode  { return 0o444 }
+func (i shardedInfo) ModTime() time.Time { return time.Time{} }
+func (i shardedInfo) IsDir() bool        { return false }
+func (i shardedInfo) Sys() any           { return nil }
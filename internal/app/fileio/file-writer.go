@@ -1,28 +1,43 @@
 package fileio
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
 	"io"
 	"os"
 	"sync"
+	"time"
 )
 
 type writer struct {
-	ownFile File
-	osFile  FsFile
-	mx      sync.Mutex
-	closed  bool
+	ownFile     File
+	osFile      FsFile
+	reservation Reservation
+	hash        hash.Hash
+	// checksum is known in advance when the content is copied by copyFrom, the content isn't hashed then
+	checksum string
+	mx       sync.Mutex
+	closed   bool
 }
 
-func newFileWriter(f *file) (io.WriteCloser, error) {
+func newFileWriter(f *file, reservation Reservation) (io.WriteCloser, error) {
 	file, err := f.openForWriting()
 	if err != nil {
 		return nil, err
 	}
+	if err := file.Truncate(0); err != nil {
+		file.Close()
+		return nil, err
+	}
 
 	return &writer{
-		ownFile: f,
-		osFile:  file,
-		mx:      sync.Mutex{},
+		ownFile:     f,
+		osFile:      file,
+		reservation: reservation,
+		hash:        sha256.New(),
+		mx:          sync.Mutex{},
 	}, nil
 }
 
@@ -34,22 +49,84 @@ func (w *writer) Write(b []byte) (n int, err error) {
 	w.mx.Lock()
 	defer w.mx.Unlock()
 
-	err = w.ownFile.allocate(int64(len(b)))
+	if w.reservation != nil {
+		err = w.reservation.Consume(int64(len(b)))
+	} else {
+		err = w.ownFile.allocate(int64(len(b)))
+	}
 	if err != nil {
 		return 0, err
 	}
 
-	return w.osFile.Write(b)
+	n, err = w.osFile.Write(b)
+	w.ownFile.grow(int64(n))
+	w.hash.Write(b[:n])
+
+	return n, err
 }
 
+// Close stores the checksum and bumps the version of the file, closing twice is a no-op.
 func (w *writer) Close() error {
 	w.mx.Lock()
 	defer w.mx.Unlock()
+	if w.closed {
+		return nil
+	}
 
 	err := w.osFile.Close()
 	w.closed = true
+	if w.reservation != nil {
+		w.reservation.Release()
+	}
+
+	if !w.ownFile.Closed() {
+		metadata := w.ownFile.Metadata()
+		metadata.Checksum = hex.EncodeToString(w.hash.Sum(nil))
+		if w.checksum != "" {
+			metadata.Checksum = w.checksum
+		}
+		metadata.Replicas = nil
+		metadata.Version++
+		metadata.ModifiedAt = time.Now()
+		err = errors.Join(err, w.ownFile.SetMetadata(metadata))
+	}
 
 	w.ownFile.rwMx().Unlock()
 
 	return err
 }
+
+// copyFrom appends size bytes of src whose checksum is known.
+func (w *writer) copyFrom(src io.Reader, size int64, checksum string) (n int64, err error) {
+	if w.ownFile.Closed() || w.closed {
+		return 0, os.ErrClosed
+	}
+
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	if w.reservation != nil {
+		err = w.reservation.Consume(size)
+	} else {
+		err = w.ownFile.allocate(size)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	limited := io.LimitReader(src, size)
+	if dst, ok := w.osFile.(io.ReaderFrom); ok {
+		n, err = dst.ReadFrom(limited)
+	} else {
+		n, err = io.Copy(w.osFile, limited)
+	}
+	w.ownFile.grow(n)
+	if err == nil && n != size {
+		err = io.ErrUnexpectedEOF
+	}
+	if err == nil {
+		w.checksum = checksum
+	}
+
+	return n, err
+}
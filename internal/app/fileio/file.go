@@ -2,19 +2,34 @@ package fileio
 
 import (
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"io"
 	"os"
 	"path"
+	"slices"
 	"sync"
+	"time"
 )
 
 //go:generate go run github.com/vektra/mockery/v2@v2.52.3 --name=File --structname=MockFile --filename=mock_file.go --inpackage
 type File interface {
 	Sync(controller StorageController) error
 	Reader(bufferSize int) (Reader, error)
-	Writer() (io.WriteCloser, error)
+	// Writer consumes the reservation, if any, instead of allocating storage and releases it on Close.
+	Writer(reservation Reservation) (io.WriteCloser, error)
+	Replace(reservation Reservation, src io.Reader, size int64, checksum string) error
 	Delete() error
+	Relocate(dir string) error
+	MoveTo(dir string, controller StorageController) error
+	Encode(dirs []string, erasure Erasure, controller StorageController) error
+	LostShards(verify bool) []int
+	RebuildShards(dirs map[int]string, controller StorageController) error
+	Metadata() Metadata
+	SetMetadata(m Metadata) error
+	Touch()
+	AccessedAt() time.Time
+	FlushAccessTime() error
 
 	ID() uuid.UUID
 	FullPath() string
@@ -24,11 +39,15 @@ type File interface {
 	rwMx() *sync.RWMutex
 
 	allocate(size int64) error
+	grow(size int64)
 	openForReading() (FsFile, error)
 	openForWriting() (FsFile, error)
 }
 
-var ErrBusy = errors.New("file is busy")
+var (
+	ErrBusy             = errors.New("file is busy")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
 
 type file struct {
 	id         uuid.UUID
@@ -38,6 +57,16 @@ type file struct {
 	mx         *sync.RWMutex
 	closed     bool
 	v          int
+	metadata   Metadata
+	// hasMetadata shows whether the metadata sidecar exists on the drive
+	hasMetadata bool
+	// accessDirty shows whether the access time has changed since the metadata was persisted
+	accessDirty bool
+	metadataMx  sync.RWMutex
+	// replaceMx serializes replacements, which share the temporary blob
+	replaceMx sync.Mutex
+	// shards are the blobs of an erasure coded file, path is the directory of the first shard then
+	shards []string
 }
 
 func NewFile(filePath string, id uuid.UUID, controller StorageController) (File, error) {
@@ -53,12 +82,66 @@ func NewFile(filePath string, id uuid.UUID, controller StorageController) (File,
 	size := stat.Size()
 	f.Close()
 
+	metadata, hasMetadata, err := loadMetadata(controller, path.Join(filePath, id.String()))
+	if err != nil {
+		return nil, err
+	}
+	if metadata.AccessedAt.IsZero() {
+		metadata.AccessedAt = stat.ModTime()
+	}
+	if metadata.ModifiedAt.IsZero() {
+		metadata.ModifiedAt = stat.ModTime()
+	}
+
 	return &file{
-		id:         id,
-		path:       filePath,
-		size:       size,
-		controller: controller,
-		mx:         &sync.RWMutex{},
+		id:          id,
+		path:        filePath,
+		size:        size,
+		controller:  controller,
+		mx:          &sync.RWMutex{},
+		metadata:    metadata,
+		hasMetadata: hasMetadata,
+	}, nil
+}
+
+// NewShardedFile loads the erasure coded file from the shards found, keyed by their indexes.
+func NewShardedFile(id uuid.UUID, shards map[int]string, controller StorageController) (File, error) {
+	var (
+		metadata Metadata
+		errs     error
+	)
+	for _, name := range shards {
+		m, exists, err := loadMetadata(controller, name)
+		if err == nil && exists && m.Erasure != nil {
+			metadata = m
+			break
+		}
+		errs = errors.Join(errs, err)
+	}
+	if metadata.Erasure == nil {
+		return nil, fmt.Errorf("no shard of %s has erasure metadata: %w", id, errors.Join(errs, os.ErrNotExist))
+	}
+
+	paths := make([]string, metadata.Erasure.Shards())
+	for index, name := range shards {
+		if index < len(paths) {
+			paths[index] = name
+		}
+	}
+	first := slices.IndexFunc(paths, func(name string) bool { return name != "" })
+	if first < 0 {
+		return nil, fmt.Errorf("shards of %s don't match erasure metadata: %w", id, os.ErrNotExist)
+	}
+
+	return &file{
+		id:          id,
+		path:        path.Dir(paths[first]),
+		size:        metadata.Erasure.Size,
+		controller:  controller,
+		mx:          &sync.RWMutex{},
+		metadata:    metadata,
+		hasMetadata: true,
+		shards:      paths,
 	}, nil
 }
 
@@ -67,6 +150,11 @@ func (f *file) Sync(controller StorageController) error {
 	if f.closed {
 		return os.ErrClosed
 	}
+	if f.shards != nil {
+		f.controller = controller
+		f.mx = &sync.RWMutex{}
+		return nil
+	}
 
 	// open file to get size
 	file, err := controller.CreateOrOpenForWriting(f.FullPath())
@@ -104,7 +192,7 @@ func (f *file) Reader(bufferSize int) (Reader, error) {
 	return reader, nil
 }
 
-func (f *file) Writer() (io.WriteCloser, error) {
+func (f *file) Writer(reservation Reservation) (io.WriteCloser, error) {
 	if f.closed {
 		return nil, os.ErrClosed
 	}
@@ -112,15 +200,31 @@ func (f *file) Writer() (io.WriteCloser, error) {
 	f.mx.Lock()
 
 	f.v++
+	f.Touch()
 
 	size := f.size
 	err := f.controller.ReleaseStorage(f.size)
 	if err != nil {
+		f.mx.Unlock()
 		return nil, err
 	}
 	f.size = 0
 
-	writer, err := newFileWriter(f)
+	// the new content is written as a whole blob, so the shards are dropped together with the old content
+	if f.shards != nil {
+		err = f.removeBlobs(f.controller)
+		f.metadataMx.Lock()
+		f.shards = nil
+		f.metadata.Erasure = nil
+		f.hasMetadata = false
+		f.metadataMx.Unlock()
+		if err != nil {
+			f.mx.Unlock()
+			return nil, err
+		}
+	}
+
+	writer, err := newFileWriter(f, reservation)
 	if err != nil {
 		f.v--
 		f.size = size
@@ -138,7 +242,462 @@ func (f *file) Delete() error {
 	defer f.mx.Unlock()
 	f.closed = true
 
-	return f.controller.FSDelete(f.FullPath())
+	if f.shards != nil {
+		return f.removeBlobs(f.controller)
+	}
+
+	if err := f.controller.FSDelete(f.FullPath()); err != nil {
+		return err
+	}
+
+	f.metadataMx.RLock()
+	defer f.metadataMx.RUnlock()
+	if f.hasMetadata {
+		return f.controller.FSDelete(metadataPath(f.FullPath()))
+	}
+
+	return nil
+}
+
+// Relocate moves the file into dir, ErrBusy is returned if the file is busy.
+func (f *file) Relocate(dir string) error {
+	if f.closed {
+		return os.ErrClosed
+	}
+	if !f.mx.TryLock() {
+		return ErrBusy
+	}
+	defer f.mx.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	// shards are placed on several disks, they stay where they have been encoded to
+	if f.shards != nil {
+		return nil
+	}
+
+	newPath := path.Join(dir, f.id.String())
+	if err := f.controller.FSRename(f.FullPath(), newPath); err != nil {
+		return err
+	}
+
+	f.metadataMx.Lock()
+	defer f.metadataMx.Unlock()
+	if f.hasMetadata {
+		if err := f.controller.FSRename(metadataPath(f.FullPath()), metadataPath(newPath)); err != nil {
+			return errors.Join(err, f.controller.FSRename(newPath, f.FullPath()))
+		}
+	}
+	f.path = dir
+
+	return nil
+}
+
+// MoveTo copies the file into dir of another controller and switches to the copy.
+func (f *file) MoveTo(dir string, controller StorageController) error {
+	if f.closed {
+		return os.ErrClosed
+	}
+	if !f.mx.TryRLock() {
+		return ErrBusy
+	}
+
+	v, oldController := f.v, f.controller
+	newPath := path.Join(dir, f.id.String())
+	metadata := f.Metadata()
+	metadata.Erasure = nil
+
+	err := copyBlob(f, controller, newPath)
+	if err == nil {
+		err = storeMetadata(controller, newPath, metadata)
+	}
+	f.mx.RUnlock()
+	if err != nil {
+		return errors.Join(err, removeBlob(controller, newPath))
+	}
+
+	if !f.mx.TryLock() {
+		return errors.Join(ErrBusy, removeBlob(controller, newPath))
+	}
+	defer f.mx.Unlock()
+	if f.closed || f.v != v {
+		return errors.Join(ErrBusy, removeBlob(controller, newPath))
+	}
+
+	err = f.removeBlobs(oldController)
+
+	f.metadataMx.Lock()
+	f.path = dir
+	f.controller = controller
+	f.hasMetadata = true
+	f.shards = nil
+	f.metadata.Erasure = nil
+	f.metadataMx.Unlock()
+
+	return err
+}
+
+// Encode splits the file into erasure coded shards, one per directory of dirs, and switches to the shards.
+func (f *file) Encode(dirs []string, erasure Erasure, controller StorageController) error {
+	if f.closed {
+		return os.ErrClosed
+	}
+	if len(dirs) != erasure.Shards() {
+		return errInvalidShards
+	}
+	if !f.mx.TryRLock() {
+		return ErrBusy
+	}
+
+	v, oldController := f.v, f.controller
+	paths := make([]string, len(dirs))
+	for i, dir := range dirs {
+		paths[i] = path.Join(dir, ShardName(f.id, i))
+	}
+	metadata := f.Metadata()
+	metadata.Erasure = &erasure
+
+	err := f.writeShards(paths, controller, func(dst []io.Writer) error {
+		src, err := f.openForReading()
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+
+		erasure.Checksums, err = encodeShards(src, dst, erasure)
+		return err
+	})
+	for i := 0; err == nil && i < len(paths); i++ {
+		err = storeMetadata(controller, paths[i], metadata)
+	}
+	f.mx.RUnlock()
+	if err != nil {
+		return errors.Join(err, removeBlobs(controller, paths))
+	}
+
+	if !f.mx.TryLock() {
+		return errors.Join(ErrBusy, removeBlobs(controller, paths))
+	}
+	defer f.mx.Unlock()
+	if f.closed || f.v != v || f.shards != nil {
+		return errors.Join(ErrBusy, removeBlobs(controller, paths))
+	}
+
+	err = f.removeBlobs(oldController)
+
+	f.metadataMx.Lock()
+	f.path = dirs[0]
+	f.controller = controller
+	f.hasMetadata = true
+	f.shards = paths
+	f.metadata.Erasure = &erasure
+	f.metadataMx.Unlock()
+
+	return err
+}
+
+// LostShards returns indexes of the shards which are missing or truncated, and with verify those which don't match their checksums.
+func (f *file) LostShards(verify bool) []int {
+	f.mx.RLock()
+	defer f.mx.RUnlock()
+	if f.shards == nil {
+		return nil
+	}
+
+	erasure := *f.Metadata().Erasure
+	var lost []int
+	for i, name := range f.shards {
+		if name == "" {
+			lost = append(lost, i)
+			continue
+		}
+		if stat, err := f.controller.Stat(name); err != nil || stat.Size() != erasure.ShardSize() {
+			lost = append(lost, i)
+			continue
+		}
+		if verify && !f.shardMatches(name, i, erasure) {
+			lost = append(lost, i)
+		}
+	}
+
+	return lost
+}
+
+func (f *file) shardMatches(name string, index int, erasure Erasure) bool {
+	shard, err := f.controller.OpenForReading(name)
+	if err != nil {
+		return false
+	}
+	defer shard.Close()
+
+	return erasure.verify(index, shard)
+}
+
+// RebuildShards regenerates the shards of dirs, keyed by their indexes, from the others.
+func (f *file) RebuildShards(dirs map[int]string, controller StorageController) error {
+	if f.closed {
+		return os.ErrClosed
+	}
+	if !f.mx.TryRLock() {
+		return ErrBusy
+	}
+	if f.shards == nil {
+		f.mx.RUnlock()
+		return nil
+	}
+
+	v := f.v
+	metadata := f.Metadata()
+	indexes := make([]int, 0, len(dirs))
+	paths := make([]string, 0, len(dirs))
+	for i, dir := range dirs {
+		indexes = append(indexes, i)
+		paths = append(paths, path.Join(dir, ShardName(f.id, i)))
+	}
+
+	// shards rebuilt into their own directories replace the lost ones in place
+	err := removeBlobs(controller, paths)
+	if err == nil {
+		err = f.writeShards(paths, controller, func(dst []io.Writer) error {
+			src, err := openShardedBlob(f.controller, f.shards, *metadata.Erasure)
+			if err != nil {
+				return err
+			}
+			defer src.Close()
+
+			writers := make(map[int]io.Writer, len(dst))
+			for i, w := range dst {
+				writers[indexes[i]] = w
+			}
+
+			return src.rebuild(writers)
+		})
+	}
+	for i := 0; err == nil && i < len(paths); i++ {
+		err = storeMetadata(controller, paths[i], metadata)
+	}
+	f.mx.RUnlock()
+	if err != nil {
+		return errors.Join(err, removeBlobs(controller, paths))
+	}
+
+	if !f.mx.TryLock() {
+		return errors.Join(ErrBusy, removeBlobs(controller, paths))
+	}
+	defer f.mx.Unlock()
+	if f.closed || f.v != v || f.shards == nil {
+		return errors.Join(ErrBusy, removeBlobs(controller, paths))
+	}
+
+	var old []string
+	f.metadataMx.Lock()
+	for j, i := range indexes {
+		if f.shards[i] != paths[j] {
+			old = append(old, f.shards[i])
+		}
+		f.shards[i] = paths[j]
+	}
+	if dir, ok := dirs[0]; ok {
+		f.path = dir
+	}
+	f.controller = controller
+	f.metadataMx.Unlock()
+
+	// shards moved away from their disks are removed if the disks can still be written
+	_ = removeBlobs(controller, old)
+
+	return nil
+}
+
+// writeShards creates the blobs of paths and lets write fill them.
+func (f *file) writeShards(paths []string, fs FileSystem, write func(dst []io.Writer) error) (err error) {
+	files := make([]FsFile, 0, len(paths))
+	defer func() {
+		for _, file := range files {
+			err = errors.Join(err, file.Close())
+		}
+	}()
+
+	dst := make([]io.Writer, len(paths))
+	for i, name := range paths {
+		if err := fs.FSDelete(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		file, err := fs.CreateOrOpenForWriting(name)
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+		dst[i] = file
+	}
+
+	if err := write(dst); err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := file.Sync(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeBlobs deletes the whole blob or the shards of the file together with their sidecars.
+func (f *file) removeBlobs(fs FileSystem) error {
+	if f.shards != nil {
+		return removeBlobs(fs, f.shards)
+	}
+
+	return removeBlob(fs, f.FullPath())
+}
+
+func removeBlobs(fs FileSystem, paths []string) error {
+	var err error
+	for _, name := range paths {
+		if name != "" {
+			err = errors.Join(err, removeBlob(fs, name))
+		}
+	}
+
+	return err
+}
+
+func copyBlob(from File, to FileSystem, toPath string) error {
+	src, err := from.openForReading()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := to.FSDelete(toPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	dst, err := to.CreateOrOpenForWriting(toPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		return err
+	}
+
+	return dst.Close()
+}
+
+// removeBlob deletes the blob together with its metadata sidecar, missing files are ignored.
+func removeBlob(fs FileSystem, blobPath string) error {
+	var err error
+	for _, name := range []string{blobPath, metadataPath(blobPath)} {
+		if err2 := fs.FSDelete(name); err2 != nil && !errors.Is(err2, os.ErrNotExist) {
+			err = errors.Join(err, err2)
+		}
+	}
+
+	return err
+}
+
+// Touch marks the file as accessed now. The access time is kept in memory until FlushAccessTime.
+func (f *file) Touch() {
+	f.metadataMx.Lock()
+	defer f.metadataMx.Unlock()
+
+	f.metadata.AccessedAt = time.Now()
+	f.accessDirty = true
+}
+
+func (f *file) AccessedAt() time.Time {
+	f.metadataMx.RLock()
+	defer f.metadataMx.RUnlock()
+
+	return f.metadata.AccessedAt
+}
+
+// FlushAccessTime persists the access time if it has changed since the metadata was stored.
+func (f *file) FlushAccessTime() error {
+	if f.closed {
+		return os.ErrClosed
+	}
+
+	f.metadataMx.Lock()
+	defer f.metadataMx.Unlock()
+	if !f.accessDirty {
+		return nil
+	}
+
+	if err := f.storeMetadata(f.metadata); err != nil {
+		return err
+	}
+	f.hasMetadata = true
+	f.accessDirty = false
+
+	return nil
+}
+
+func (f *file) Metadata() Metadata {
+	f.metadataMx.RLock()
+	defer f.metadataMx.RUnlock()
+
+	return f.metadata
+}
+
+// SetMetadata persists metadata into the sidecar of the file.
+func (f *file) SetMetadata(m Metadata) error {
+	if f.closed {
+		return os.ErrClosed
+	}
+
+	f.metadataMx.Lock()
+	defer f.metadataMx.Unlock()
+
+	if m.AccessedAt.IsZero() {
+		m.AccessedAt = f.metadata.AccessedAt
+	}
+	if m.Version == 0 {
+		m.Version, m.ModifiedAt = f.metadata.Version, f.metadata.ModifiedAt
+	}
+	// the layout of the content is owned by the file
+	m.Erasure = f.metadata.Erasure
+	if err := f.storeMetadata(m); err != nil {
+		return err
+	}
+	f.metadata = m
+	f.hasMetadata = true
+	f.accessDirty = false
+
+	return nil
+}
+
+// storeMetadata persists the metadata next to the whole blob or next to every shard.
+func (f *file) storeMetadata(m Metadata) error {
+	if f.shards == nil {
+		return storeMetadata(f.controller, f.FullPath(), m)
+	}
+
+	var (
+		stored int
+		errs   error
+	)
+	for _, name := range f.shards {
+		if name == "" {
+			continue
+		}
+		if err := storeMetadata(f.controller, name, m); err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		stored++
+	}
+	if stored == 0 {
+		return errors.Join(ErrTooFewShards, errs)
+	}
+
+	return nil
 }
 
 func (f *file) Closed() bool {
@@ -160,11 +719,20 @@ func (f *file) allocate(size int64) error {
 	return f.controller.AllocateStorage(size)
 }
 
+// grow accounts bytes appended by the writer, which holds the lock of the file.
+func (f *file) grow(size int64) {
+	f.size += size
+}
+
 func (f *file) rwMx() *sync.RWMutex {
 	return f.mx
 }
 
 func (f *file) openForReading() (FsFile, error) {
+	if f.shards != nil {
+		return openShardedBlob(f.controller, f.shards, *f.Metadata().Erasure)
+	}
+
 	return f.controller.OpenForReading(f.FullPath())
 }
 
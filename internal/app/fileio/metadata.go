@@ -0,0 +1,91 @@
+package fileio
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// metadataSuffix is appended to the blob name to get the name of its metadata sidecar.
+const metadataSuffix = ".meta"
+
+// Metadata is persisted next to the blob in a sidecar file.
+type Metadata struct {
+	OwnerID    string    `json:"ownerId,omitempty"`
+	AccessedAt time.Time `json:"accessedAt,omitzero"`
+	// ExpiresAt is the time the file is deleted at, zero stands for never.
+	ExpiresAt time.Time `json:"expiresAt,omitzero"`
+	// Checksum is the hex encoded SHA-256 of the content, it is updated every time the file is written.
+	Checksum string `json:"checksum,omitempty"`
+	// Version is incremented and ModifiedAt is set every time the file is written.
+	Version    uint64    `json:"version,omitempty"`
+	ModifiedAt time.Time `json:"modifiedAt,omitzero"`
+	// Replicas are hosts of the peer nodes which have acknowledged a copy of the current content.
+	Replicas []string `json:"replicas,omitempty"`
+	// Erasure is set for erasure coded files, whose content is stored in shards.
+	Erasure *Erasure `json:"erasure,omitempty"`
+}
+
+// Checksum returns the hex encoded SHA-256 of the content read from r.
+func Checksum(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (m Metadata) Expired(now time.Time) bool {
+	return !m.ExpiresAt.IsZero() && !now.Before(m.ExpiresAt)
+}
+
+func metadataPath(blobPath string) string {
+	return blobPath + metadataSuffix
+}
+
+// loadMetadata reads the sidecar of the blob. Blobs without a sidecar have empty metadata.
+func loadMetadata(fs FileSystem, blobPath string) (m Metadata, exists bool, err error) {
+	f, err := fs.OpenForReading(metadataPath(blobPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return m, false, nil
+	}
+	if err != nil {
+		return m, false, err
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(&m); err != nil {
+		return m, true, err
+	}
+
+	return m, true, nil
+}
+
+// storeMetadata replaces the sidecar of the blob atomically.
+func storeMetadata(fs FileSystem, blobPath string, m Metadata) error {
+	name := metadataPath(blobPath)
+	tmpName := name + ".tmp"
+
+	if err := fs.FSDelete(tmpName); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	f, err := fs.CreateOrOpenForWriting(tmpName)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(m); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return fs.FSRename(tmpName, name)
+}
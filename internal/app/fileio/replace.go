@@ -0,0 +1,91 @@
+package fileio
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+)
+
+// Replace swaps the content of the file for size bytes of src once they match checksum.
+func (f *file) Replace(reservation Reservation, src io.Reader, size int64, checksum string) error {
+	defer reservation.Release()
+	if f.closed {
+		return os.ErrClosed
+	}
+
+	f.replaceMx.Lock()
+	defer f.replaceMx.Unlock()
+
+	f.mx.RLock()
+	v, controller, blobPath := f.v, f.controller, f.FullPath()
+	f.mx.RUnlock()
+	tmpPath := blobPath + ".tmp"
+
+	if err := reservation.Consume(size); err != nil {
+		return err
+	}
+	written, err := writeBlob(controller, tmpPath, src, size)
+	if err == nil && checksum != "" && written != checksum {
+		err = ErrChecksumMismatch
+	}
+	if err != nil {
+		reservation.Refund(size)
+		return errors.Join(err, removeBlob(controller, tmpPath))
+	}
+
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if f.closed || f.v != v || f.controller != controller || f.FullPath() != blobPath {
+		reservation.Refund(size)
+		return errors.Join(ErrBusy, removeBlob(controller, tmpPath))
+	}
+	if err := controller.FSRename(tmpPath, blobPath); err != nil {
+		reservation.Refund(size)
+		return errors.Join(err, removeBlob(controller, tmpPath))
+	}
+
+	// the old content is given back once the new one is in place, shards are dropped like the writer does
+	err = controller.ReleaseStorage(f.size)
+	if f.shards != nil {
+		err = errors.Join(err, removeBlobs(controller, f.shards))
+		f.metadataMx.Lock()
+		f.shards = nil
+		f.metadata.Erasure = nil
+		f.hasMetadata = false
+		f.metadataMx.Unlock()
+	}
+	f.size = size
+	f.v++
+
+	metadata := f.Metadata()
+	metadata.Checksum = written
+
+	return errors.Join(err, f.SetMetadata(metadata))
+}
+
+// writeBlob writes size bytes of src into the blob and returns their checksum.
+func writeBlob(fs FileSystem, name string, src io.Reader, size int64) (string, error) {
+	if err := fs.FSDelete(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	dst, err := fs.CreateOrOpenForWriting(name)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	n, err := io.CopyN(io.MultiWriter(dst, hash), src, size)
+	if err == nil && n != size {
+		err = io.ErrUnexpectedEOF
+	}
+	if err == nil {
+		err = dst.Sync()
+	}
+	if err = errors.Join(err, dst.Close()); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
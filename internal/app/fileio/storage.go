@@ -10,6 +10,7 @@ type FileSystem interface {
 	OpenForReading(name string) (FsFile, error)
 	Stat(name string) (os.FileInfo, error)
 	FSDelete(name string) error
+	FSRename(oldName, newName string) error
 	CreateOrOpenForWriting(name string) (FsFile, error)
 }
 
@@ -20,6 +21,8 @@ type FsFile interface {
 	io.Seeker
 	io.Writer
 	Stat() (os.FileInfo, error)
+	Sync() error
+	Truncate(size int64) error
 }
 
 //go:generate go run github.com/vektra/mockery/v2@v2.52.3 --name=StorageController --filename=mock_storage.go
@@ -32,3 +35,12 @@ type StorageController interface {
 	DeleteFile(id uuid.UUID) error
 	File(id uuid.UUID) (File, error)
 }
+
+// Reservation is space reserved for an upload in advance. Writes consume it instead of allocating storage.
+type Reservation interface {
+	Consume(size int64) error
+	// Refund gives back storage consumed by content which has been discarded.
+	Refund(size int64)
+	Release()
+	Released() bool
+}
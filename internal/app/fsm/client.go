@@ -0,0 +1,188 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// IdempotencyKeyHeader lets the FSM recognise retries of a callback it has already processed.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+var ErrCircuitOpen = errors.New("fsm circuit breaker is open")
+
+type Options struct {
+	Token string
+	// Timeout bounds every attempt.
+	Timeout time.Duration
+	// Retries is the number of attempts after the first one.
+	Retries int
+	// Backoff is the base delay before a retry, it doubles with every attempt up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// BreakerThreshold consecutive failures open the breaker of the FSM host for BreakerCooldown
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// Client makes callbacks to the FSM, retrying transient failures.
+type Client struct {
+	client   *resty.Client
+	options  Options
+	breakers map[string]*breaker
+	mx       sync.Mutex
+}
+
+func New(options Options) *Client {
+	return &Client{
+		client:   resty.New().SetTimeout(options.Timeout),
+		options:  options,
+		breakers: make(map[string]*breaker),
+	}
+}
+
+// Post sends the body of the content type to the FSM. Any 2xx response is returned, the caller decides on its status code.
+func (c *Client) Post(ctx context.Context, link, key, contentType string, body []byte) (*resty.Response, error) {
+	return c.Do(ctx, http.MethodPost, link, key, http.Header{"Content-Type": {contentType}}, body)
+}
+
+func (c *Client) Delete(ctx context.Context, link, key string) error {
+	_, err := c.Do(ctx, http.MethodDelete, link, key, nil, nil)
+
+	return err
+}
+
+// Do makes the request until it succeeds, fails permanently with a 4xx status, the retries run out or ctx is done.
+func (c *Client) Do(ctx context.Context, method, link, key string, header http.Header, body []byte) (*resty.Response, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+	b := c.breaker(u.Host)
+
+	for attempt := 0; ; attempt++ {
+		allowed, probe := b.allow()
+		if !allowed {
+			return nil, ErrCircuitOpen
+		}
+
+		result, err := c.attempt(ctx, method, link, key, header, body)
+		b.record(probe, err == nil || !retryable(result))
+		if err == nil {
+			return result, nil
+		}
+		if !retryable(result) || attempt == c.options.Retries {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Join(err, ctx.Err())
+		case <-time.After(c.backoff(attempt)):
+		}
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, method, link, key string, header http.Header, body []byte) (*resty.Response, error) {
+	request := c.client.R().
+		SetContext(ctx).
+		SetAuthScheme("Bearer").
+		SetAuthToken(c.options.Token).
+		SetHeaderMultiValues(header).
+		SetHeader(IdempotencyKeyHeader, key)
+	if body != nil {
+		request.SetBody(body)
+	}
+
+	result, err := request.Execute(method, link)
+	if err != nil {
+		return nil, err
+	}
+	if result.IsError() {
+		return result, fmt.Errorf("fsm responded with %s", result.Status())
+	}
+
+	return result, nil
+}
+
+// retryable reports whether the request has failed transiently: without a response, with a 5xx status or 429.
+func retryable(result *resty.Response) bool {
+	if result == nil {
+		return true
+	}
+
+	return result.StatusCode() >= http.StatusInternalServerError || result.StatusCode() == http.StatusTooManyRequests
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := min(c.options.Backoff<<attempt, c.options.MaxBackoff)
+	if delay <= 0 {
+		return 0
+	}
+
+	return rand.N(delay + 1)
+}
+
+func (c *Client) breaker(host string) *breaker {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	b, ok := c.breakers[host]
+	if !ok {
+		b = &breaker{threshold: c.options.BreakerThreshold, cooldown: c.options.BreakerCooldown}
+		c.breakers[host] = b
+	}
+
+	return b
+}
+
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool
+	mx        sync.Mutex
+}
+
+// allow reports whether the call may be made, and whether it is the probe of the open breaker.
+func (b *breaker) allow() (allowed, probe bool) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return true, false
+	}
+	// only a single probe is let through once the cooldown has passed
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false, false
+	}
+	b.probing = true
+
+	return true, true
+}
+
+// record counts the result of a call, only the probe itself lets another probe through.
+func (b *breaker) record(probe, success bool) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+	if success {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.threshold > 0 && b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
@@ -0,0 +1,96 @@
+package fsm
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_RetriesWithIdempotencyKey(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "request-id", r.Header.Get(IdempotencyKeyHeader))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusResetContent)
+	}))
+	defer server.Close()
+
+	c := New(Options{Token: "token", Timeout: time.Second, Retries: 5, Backoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond})
+	result, err := c.Post(context.Background(), server.URL, "request-id", "application/json", []byte("{}"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusResetContent, result.StatusCode())
+	assert.EqualValues(t, 3, attempts.Load())
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		attempts.Store(0)
+		require.Error(t, c.Delete(context.Background(), server.URL, "request-id"))
+		assert.EqualValues(t, 1, attempts.Load())
+	})
+}
+
+func TestClient_BreakerOpensAndRecovers(t *testing.T) {
+	var healthy atomic.Bool
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	c := New(Options{Timeout: time.Second, Retries: 5, Backoff: time.Millisecond, MaxBackoff: time.Millisecond, BreakerThreshold: 3, BreakerCooldown: 50 * time.Millisecond})
+	require.ErrorIs(t, c.Delete(context.Background(), server.URL, "1"), ErrCircuitOpen)
+	assert.EqualValues(t, 3, attempts.Load())
+
+	require.ErrorIs(t, c.Delete(context.Background(), server.URL, "2"), ErrCircuitOpen)
+	assert.EqualValues(t, 3, attempts.Load(), "open breaker must fail fast")
+
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	require.NoError(t, c.Delete(context.Background(), server.URL, "3"))
+	require.NoError(t, c.Delete(context.Background(), server.URL, "4"))
+	assert.EqualValues(t, 5, attempts.Load())
+}
+
+func TestBreaker_OnlyTheProbeLetsAnotherProbeThrough(t *testing.T) {
+	b := &breaker{threshold: 1, cooldown: time.Millisecond}
+
+	// a call allowed while the breaker was closed, and still in flight once it opens
+	allowed, probe := b.allow()
+	require.True(t, allowed)
+	require.False(t, probe)
+
+	b.record(false, false)
+	time.Sleep(2 * time.Millisecond)
+	allowed, probe = b.allow()
+	require.True(t, allowed)
+	require.True(t, probe)
+
+	b.record(false, false)
+	time.Sleep(2 * time.Millisecond)
+	allowed, _ = b.allow()
+	assert.False(t, allowed, "a late call must not let a second probe through")
+
+	b.record(true, true)
+	allowed, probe = b.allow()
+	assert.True(t, allowed)
+	assert.False(t, probe, "breaker is closed after a successful probe")
+}
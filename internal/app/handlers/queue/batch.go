@@ -0,0 +1,73 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/sync/errgroup"
+	"log/slog"
+)
+
+var (
+	ErrNestedBatch    = errors.New("batch items can't be batches or reverts")
+	ErrNothingToServe = errors.New("no item of the batch is served by the node")
+)
+
+// processBatch processes the items of the batch, leaving out the ones the node doesn't serve.
+func (h *Handler) processBatch(ctx context.Context, r *Request) (*Response, error) {
+	l := h.l.With(slog.String("op", "processBatch"))
+
+	responses := make([]*Response, len(r.Items))
+	var g errgroup.Group
+	g.SetLimit(h.batchConcurrency)
+	for i := range r.Items {
+		item := &r.Items[i]
+		if item.Host == "" {
+			item.Host = r.Host
+		}
+
+		g.Go(func() error {
+			response, err := h.processItem(ctx, item)
+			if notServed(err) {
+				l.Debug("batch item is not served", slog.String("id", item.ID.String()), slog.String("err", err.Error()))
+				return nil
+			}
+			if err != nil {
+				l.Error("unable to process batch item", slog.String("id", item.ID.String()), slog.String("err", err.Error()))
+				response = &Response{ID: item.ID, Host: h.host, Err: err.Error()}
+			}
+			responses[i] = response
+
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	response := &Response{ID: r.ID, Host: h.host}
+	for _, itemResponse := range responses {
+		if itemResponse != nil {
+			response.Items = append(response.Items, *itemResponse)
+		}
+	}
+	if len(response.Items) == 0 {
+		return nil, ErrNothingToServe
+	}
+	l.Info("batch has been processed", slog.String("id", r.ID.String()), slog.Int("items", len(r.Items)), slog.Int("served", len(response.Items)))
+
+	return response, nil
+}
+
+// processItem processes a single item of the batch, a panic fails the item only.
+func (h *Handler) processItem(ctx context.Context, item *Request) (response *Response, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			response, err = nil, fmt.Errorf("panic while processing batch item: %v", r)
+		}
+	}()
+
+	if item.Type == BatchType || item.Type == RevertType {
+		return nil, ErrNestedBatch
+	}
+
+	return h.processRequest(ctx, item)
+}
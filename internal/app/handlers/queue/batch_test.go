@@ -0,0 +1,42 @@
+package queue
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestHandler_ProcessBatch(t *testing.T) {
+	h := newTestHandler(t)
+	batch := &Request{
+		ID:   uuid.New(),
+		Type: BatchType,
+		Items: []Request{
+			{ID: uuid.New(), Type: QuotaType, OwnerID: "first", QuotaBytes: 100},
+			{ID: uuid.New(), Type: QuotaType, OwnerID: "second", QuotaFiles: 10},
+			{ID: uuid.New(), Type: UsageType, OwnerID: "missing"},
+			{ID: uuid.New(), Type: BatchType},
+		},
+	}
+
+	response, err := h.processRequest(context.Background(), batch)
+	require.NoError(t, err)
+	assert.Equal(t, batch.ID, response.ID)
+	require.Len(t, response.Items, 3, "items the node doesn't serve must be left out")
+	assert.Equal(t, batch.Items[0].ID, response.Items[0].ID)
+	assert.Equal(t, batch.Items[1].ID, response.Items[1].ID)
+	assert.Empty(t, response.Items[1].Err)
+	assert.Equal(t, batch.Items[3].ID, response.Items[2].ID)
+	assert.Equal(t, ErrNestedBatch.Error(), response.Items[2].Err, "failed items must be answered with their errors")
+
+	usage, err := h.ctrl.OwnerUsage("second")
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, usage.Quota.MaxFiles)
+
+	t.Run("batch with nothing to serve is not answered", func(t *testing.T) {
+		_, err := h.processRequest(context.Background(), &Request{ID: uuid.New(), Type: BatchType, Items: batch.Items[2:3]})
+		assert.ErrorIs(t, err, ErrNothingToServe)
+	})
+}
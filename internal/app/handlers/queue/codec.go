@@ -0,0 +1,358 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	queuev1 "github.com/StratuStore/file-storage/api/queue/v1"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+// Content types of queue messages, the FSM picks one per request and the response is encoded the same way.
+const (
+	GobContentType      = "application/x-gob"
+	JSONContentType     = "application/json"
+	ProtobufContentType = "application/x-protobuf"
+)
+
+// SchemaVersion is the version of the schemas in api/queue, it changes on incompatible changes only.
+const SchemaVersion = "1"
+
+// Envelope metadata of queue messages, it is published as AMQP headers. Messages without it are gob encoded.
+const (
+	ContentTypeKey   = "content_type"
+	SchemaVersionKey = "schema_version"
+)
+
+var (
+	ErrUnsupportedContentType   = errors.New("unsupported content type")
+	ErrUnsupportedSchemaVersion = errors.New("unsupported schema version")
+)
+
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// codecFor returns the codec of the content type, gob is the default for messages of older FSMs.
+func codecFor(contentType string) (Codec, error) {
+	switch contentType {
+	case "", GobContentType:
+		return &GobMarshaler{}, nil
+	case JSONContentType:
+		return jsonCodec{}, nil
+	case ProtobufContentType:
+		return protoCodec{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+}
+
+// decodeRequest decodes the request using the envelope of the message.
+func decodeRequest(msg *message.Message, request *Request) error {
+	if version := msg.Metadata.Get(SchemaVersionKey); version != "" && version != SchemaVersion {
+		return fmt.Errorf("%w: %q", ErrUnsupportedSchemaVersion, version)
+	}
+
+	contentType := msg.Metadata.Get(ContentTypeKey)
+	codec, err := codecFor(contentType)
+	if err != nil {
+		return err
+	}
+	if err := codec.Unmarshal(msg.Payload, request); err != nil {
+		return err
+	}
+	request.contentType = contentType
+
+	return nil
+}
+
+// encodeResponse encodes the response the same way as the request, returning the content type to be sent with it.
+func encodeResponse(request *Request, response *Response) ([]byte, string, error) {
+	contentType := request.contentType
+	if contentType == "" {
+		contentType = GobContentType
+	}
+
+	codec, err := codecFor(contentType)
+	if err != nil {
+		return nil, "", err
+	}
+	body, err := codec.Marshal(response)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return body, contentType, nil
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// protoCodec encodes the messages as the types generated from api/queue/v1/queue.proto.
+type protoCodec struct{}
+
+func (protoCodec) Marshal(v any) ([]byte, error) {
+	switch v := v.(type) {
+	case *Request:
+		return proto.Marshal(requestToProto(v))
+	case *Response:
+		return proto.Marshal(responseToProto(v))
+	case *Notification:
+		return proto.Marshal(notificationToProto(v))
+	case *Heartbeat:
+		return proto.Marshal(heartbeatToProto(v))
+	default:
+		return nil, fmt.Errorf("%w: %T can't be encoded with protobuf", ErrUnsupportedContentType, v)
+	}
+}
+
+func (protoCodec) Unmarshal(data []byte, v any) error {
+	switch v := v.(type) {
+	case *Request:
+		var m queuev1.Request
+		if err := proto.Unmarshal(data, &m); err != nil {
+			return err
+		}
+		return requestFromProto(&m, v)
+	case *Response:
+		var m queuev1.Response
+		if err := proto.Unmarshal(data, &m); err != nil {
+			return err
+		}
+		return responseFromProto(&m, v)
+	default:
+		return fmt.Errorf("%w: %T can't be decoded with protobuf", ErrUnsupportedContentType, v)
+	}
+}
+
+func requestToProto(r *Request) *queuev1.Request {
+	m := &queuev1.Request{
+		Id:         uuidString(r.ID),
+		Host:       r.Host,
+		Type:       queuev1.RequestType(r.Type),
+		FileId:     uuidString(r.FileID),
+		Size:       uint64(r.Size),
+		OwnerId:    r.OwnerID,
+		QuotaBytes: uint64(r.QuotaBytes),
+		QuotaFiles: uint64(r.QuotaFiles),
+		ExpiresAt:  timestampOf(r.ExpiresAt),
+		Replicas:   r.Replicas,
+		ReplyTo:    r.ReplyTo,
+		RevertId:   uuidString(r.RevertID),
+		CopyId:     uuidString(r.CopyID),
+		Cursor:     r.Cursor,
+		Limit:      uint64(r.Limit),
+		Source:     r.Source,
+		Target:     r.Target,
+		Push:       r.Push,
+	}
+	for _, item := range r.Items {
+		m.Items = append(m.Items, requestToProto(&item))
+	}
+
+	return m
+}
+
+func requestFromProto(m *queuev1.Request, r *Request) error {
+	*r = Request{
+		Host:       m.GetHost(),
+		Type:       RequestType(m.GetType()),
+		Size:       uint(m.GetSize()),
+		OwnerID:    m.GetOwnerId(),
+		QuotaBytes: uint(m.GetQuotaBytes()),
+		QuotaFiles: uint(m.GetQuotaFiles()),
+		ExpiresAt:  timeOf(m.GetExpiresAt()),
+		Replicas:   m.GetReplicas(),
+		ReplyTo:    m.GetReplyTo(),
+		Cursor:     m.GetCursor(),
+		Limit:      uint(m.GetLimit()),
+		Source:     m.GetSource(),
+		Target:     m.GetTarget(),
+		Push:       m.GetPush(),
+	}
+	err := errors.Join(
+		parseUUID(m.GetId(), &r.ID),
+		parseUUID(m.GetFileId(), &r.FileID),
+		parseUUID(m.GetRevertId(), &r.RevertID),
+		parseUUID(m.GetCopyId(), &r.CopyID),
+	)
+	for _, m := range m.GetItems() {
+		var item Request
+		err = errors.Join(err, requestFromProto(m, &item))
+		r.Items = append(r.Items, item)
+	}
+
+	return err
+}
+
+func responseToProto(r *Response) *queuev1.Response {
+	m := &queuev1.Response{
+		Id:           uuidString(r.ID),
+		Host:         r.Host,
+		ConnectionId: uuidString(r.ConnectionID),
+		Err:          r.Err,
+		Size:         r.Size,
+		Checksum:     r.Checksum,
+		Cursor:       r.Cursor,
+	}
+	for _, u := range r.Usage {
+		m.Usage = append(m.Usage, &queuev1.OwnerUsage{
+			OwnerId:    u.OwnerID,
+			Used:       u.Used,
+			Reserved:   u.Reserved,
+			Files:      u.Files,
+			QuotaBytes: u.QuotaBytes,
+			QuotaFiles: u.QuotaFiles,
+		})
+	}
+	for _, item := range r.Items {
+		m.Items = append(m.Items, responseToProto(&item))
+	}
+	for _, f := range r.Files {
+		m.Files = append(m.Files, &queuev1.FileInfo{
+			FileId:       uuidString(f.FileID),
+			Size:         f.Size,
+			Checksum:     f.Checksum,
+			Version:      f.Version,
+			OwnerId:      f.OwnerID,
+			AccessedAt:   timestampOf(f.AccessedAt),
+			ModifiedAt:   timestampOf(f.ModifiedAt),
+			ExpiresAt:    timestampOf(f.ExpiresAt),
+			Replicas:     f.Replicas,
+			ErasureCoded: f.ErasureCoded,
+		})
+	}
+
+	return m
+}
+
+func responseFromProto(m *queuev1.Response, r *Response) error {
+	*r = Response{
+		Host:     m.GetHost(),
+		Err:      m.GetErr(),
+		Size:     m.GetSize(),
+		Checksum: m.GetChecksum(),
+		Cursor:   m.GetCursor(),
+	}
+	err := errors.Join(parseUUID(m.GetId(), &r.ID), parseUUID(m.GetConnectionId(), &r.ConnectionID))
+	for _, u := range m.GetUsage() {
+		r.Usage = append(r.Usage, OwnerUsage{
+			OwnerID:    u.GetOwnerId(),
+			Used:       u.GetUsed(),
+			Reserved:   u.GetReserved(),
+			Files:      u.GetFiles(),
+			QuotaBytes: u.GetQuotaBytes(),
+			QuotaFiles: u.GetQuotaFiles(),
+		})
+	}
+	for _, m := range m.GetItems() {
+		var item Response
+		err = errors.Join(err, responseFromProto(m, &item))
+		r.Items = append(r.Items, item)
+	}
+	for _, f := range m.GetFiles() {
+		file := FileInfo{
+			Size:         f.GetSize(),
+			Checksum:     f.GetChecksum(),
+			Version:      f.GetVersion(),
+			OwnerID:      f.GetOwnerId(),
+			AccessedAt:   timeOf(f.GetAccessedAt()),
+			ModifiedAt:   timeOf(f.GetModifiedAt()),
+			ExpiresAt:    timeOf(f.GetExpiresAt()),
+			Replicas:     f.GetReplicas(),
+			ErasureCoded: f.GetErasureCoded(),
+		}
+		err = errors.Join(err, parseUUID(f.GetFileId(), &file.FileID))
+		r.Files = append(r.Files, file)
+	}
+
+	return err
+}
+
+func notificationToProto(n *Notification) *queuev1.Notification {
+	m := &queuev1.Notification{
+		Id:       uuidString(n.ID),
+		Host:     n.Host,
+		Type:     queuev1.NotificationType(n.Type),
+		Err:      n.Err,
+		Size:     n.Size,
+		Checksum: n.Checksum,
+	}
+	for _, id := range n.FileIDs {
+		m.FileIds = append(m.FileIds, id.String())
+	}
+	for _, replica := range n.Replicas {
+		m.Replicas = append(m.Replicas, &queuev1.ReplicaStatus{Host: replica.Host, Acknowledged: replica.Acknowledged, Err: replica.Err})
+	}
+
+	return m
+}
+
+func heartbeatToProto(h *Heartbeat) *queuev1.Heartbeat {
+	return &queuev1.Heartbeat{
+		Id:                uuidString(h.ID),
+		Host:              h.Host,
+		Type:              queuev1.HeartbeatType(h.Type),
+		Version:           h.Version,
+		State:             queuev1.NodeState(h.State),
+		MaxSize:           h.MaxSize,
+		CurrentSize:       h.CurrentSize,
+		Reserved:          h.Reserved,
+		Free:              h.Free,
+		FileConnections:   int64(h.FileConnections),
+		ReaderConnections: int64(h.ReaderConnections),
+		SentAt:            timestampOf(h.SentAt),
+	}
+}
+
+// uuidString and parseUUID send zero UUIDs as empty strings.
+func uuidString(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return ""
+	}
+
+	return id.String()
+}
+
+func parseUUID(s string, id *uuid.UUID) error {
+	if s == "" {
+		return nil
+	}
+
+	parsed, err := uuid.Parse(s)
+	if err != nil {
+		return fmt.Errorf("invalid uuid %q: %w", s, err)
+	}
+	*id = parsed
+
+	return nil
+}
+
+// timestampOf and timeOf leave zero times unset.
+func timestampOf(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(t)
+}
+
+func timeOf(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+
+	return ts.AsTime()
+}
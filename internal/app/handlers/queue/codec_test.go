@@ -0,0 +1,184 @@
+package queue
+
+import (
+	queuev1 "github.com/StratuStore/file-storage/api/queue/v1"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"testing"
+	"time"
+)
+
+func TestCodec_RoundTripsEveryContentType(t *testing.T) {
+	request := Request{
+		ID:         uuid.New(),
+		Host:       "http://fsm:8080",
+		Type:       CreateType,
+		FileID:     uuid.New(),
+		Size:       42,
+		OwnerID:    "owner",
+		QuotaBytes: 100,
+		QuotaFiles: 10,
+		ExpiresAt:  time.Date(2030, 1, 2, 3, 4, 5, 6, time.UTC),
+		Replicas:   []string{"http://fs-2:5000", "http://fs-3:5000"},
+		ReplyTo:    "fsm_1",
+		RevertID:   uuid.New(),
+		Items:      []Request{{ID: uuid.New(), Type: DeleteType, FileID: uuid.New()}},
+		CopyID:     uuid.New(),
+		Cursor:     "cursor",
+		Limit:      50,
+		Source:     "http://fs-2:5000",
+		Target:     "http://fs-1:5000",
+		Push:       true,
+	}
+	response := Response{
+		ID:           request.ID,
+		Host:         "http://fs-1:5000",
+		ConnectionID: uuid.New(),
+		Err:          "busy",
+		Usage:        []OwnerUsage{{OwnerID: "owner", Used: 42, Reserved: 8, Files: 1, QuotaBytes: 100, QuotaFiles: 10}},
+		Items:        []Response{{ID: request.Items[0].ID, Host: "http://fs-1:5000", Err: "busy"}},
+		Size:         42,
+		Checksum:     "checksum",
+		Files: []FileInfo{{
+			FileID:       request.FileID,
+			Size:         42,
+			Checksum:     "checksum",
+			Version:      3,
+			OwnerID:      "owner",
+			AccessedAt:   time.Date(2029, 1, 2, 3, 4, 5, 6, time.UTC),
+			ModifiedAt:   time.Date(2029, 1, 2, 3, 4, 5, 0, time.UTC),
+			ExpiresAt:    time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+			Replicas:     []string{"http://fs-2:5000"},
+			ErasureCoded: true,
+		}},
+		Cursor: uuid.NewString(),
+	}
+
+	for _, contentType := range []string{"", GobContentType, JSONContentType, ProtobufContentType} {
+		t.Run(contentType, func(t *testing.T) {
+			codec, err := codecFor(contentType)
+			require.NoError(t, err)
+
+			payload, err := codec.Marshal(&request)
+			require.NoError(t, err)
+			msg := message.NewMessage(uuid.NewString(), payload)
+			msg.Metadata.Set(ContentTypeKey, contentType)
+			msg.Metadata.Set(SchemaVersionKey, SchemaVersion)
+
+			var decoded Request
+			require.NoError(t, decodeRequest(msg, &decoded))
+			expected := request
+			expected.contentType = contentType
+			assert.Equal(t, expected, decoded)
+
+			body, responseType, err := encodeResponse(&decoded, &response)
+			require.NoError(t, err)
+			if contentType != "" {
+				assert.Equal(t, contentType, responseType)
+			}
+
+			var decodedResponse Response
+			require.NoError(t, codec.Unmarshal(body, &decodedResponse))
+			assert.Equal(t, response, decodedResponse)
+		})
+	}
+
+	t.Run("unknown protobuf fields are skipped", func(t *testing.T) {
+		payload, err := protoCodec{}.Marshal(&request)
+		require.NoError(t, err)
+		payload = protowire.AppendTag(payload, 100, protowire.BytesType)
+		payload = protowire.AppendString(payload, "added by a newer fsm")
+
+		var decoded Request
+		require.NoError(t, protoCodec{}.Unmarshal(payload, &decoded))
+		assert.Equal(t, request.ID, decoded.ID)
+	})
+
+	t.Run("unsupported envelopes are rejected", func(t *testing.T) {
+		msg := message.NewMessage(uuid.NewString(), nil)
+		msg.Metadata.Set(ContentTypeKey, "application/xml")
+		assert.ErrorIs(t, decodeRequest(msg, &Request{}), ErrUnsupportedContentType)
+
+		msg.Metadata.Set(ContentTypeKey, JSONContentType)
+		msg.Metadata.Set(SchemaVersionKey, "2")
+		assert.ErrorIs(t, decodeRequest(msg, &Request{}), ErrUnsupportedSchemaVersion)
+	})
+}
+
+func TestProtoCodec_MapsNotificationsAndHeartbeats(t *testing.T) {
+	sentAt := time.Date(2030, 1, 2, 3, 4, 5, 6, time.UTC)
+	notification := Notification{
+		ID:       uuid.New(),
+		Host:     "http://fs-1:5000",
+		Type:     MigrationFailedNotification,
+		FileIDs:  []uuid.UUID{uuid.New()},
+		Replicas: []ReplicaStatus{{Host: "http://fs-2:5000", Acknowledged: true, Err: "full"}},
+		Err:      "boom",
+		Size:     42,
+		Checksum: "checksum",
+	}
+	heartbeat := Heartbeat{
+		ID:                uuid.New(),
+		Host:              "http://fs-1:5000",
+		Type:              DeregisterHeartbeat,
+		Version:           "1.2.3",
+		State:             DrainingState,
+		MaxSize:           1000,
+		CurrentSize:       400,
+		Reserved:          100,
+		Free:              500,
+		FileConnections:   3,
+		ReaderConnections: 2,
+		SentAt:            sentAt,
+	}
+
+	payload, err := protoCodec{}.Marshal(&notification)
+	require.NoError(t, err)
+	var decodedNotification queuev1.Notification
+	require.NoError(t, proto.Unmarshal(payload, &decodedNotification))
+	assert.True(t, proto.Equal(&queuev1.Notification{
+		Id:       notification.ID.String(),
+		Host:     notification.Host,
+		Type:     queuev1.NotificationType_NOTIFICATION_TYPE_MIGRATION_FAILED,
+		FileIds:  []string{notification.FileIDs[0].String()},
+		Replicas: []*queuev1.ReplicaStatus{{Host: "http://fs-2:5000", Acknowledged: true, Err: "full"}},
+		Err:      notification.Err,
+		Size:     notification.Size,
+		Checksum: notification.Checksum,
+	}, &decodedNotification), decodedNotification.String())
+
+	payload, err = protoCodec{}.Marshal(&heartbeat)
+	require.NoError(t, err)
+	var decodedHeartbeat queuev1.Heartbeat
+	require.NoError(t, proto.Unmarshal(payload, &decodedHeartbeat))
+	assert.True(t, proto.Equal(&queuev1.Heartbeat{
+		Id:                heartbeat.ID.String(),
+		Host:              heartbeat.Host,
+		Type:              queuev1.HeartbeatType_HEARTBEAT_TYPE_DEREGISTER,
+		Version:           heartbeat.Version,
+		State:             queuev1.NodeState_NODE_STATE_DRAINING,
+		MaxSize:           heartbeat.MaxSize,
+		CurrentSize:       heartbeat.CurrentSize,
+		Reserved:          heartbeat.Reserved,
+		Free:              heartbeat.Free,
+		FileConnections:   3,
+		ReaderConnections: 2,
+		SentAt:            timestamppb.New(sentAt),
+	}, &decodedHeartbeat), decodedHeartbeat.String())
+
+	t.Run("enums match queue.proto", func(t *testing.T) {
+		assert.Equal(t, "REQUEST_TYPE_INGEST", queuev1.RequestType(IngestType).String())
+		assert.Len(t, queuev1.RequestType_name, int(IngestType)+1)
+		assert.Equal(t, "NOTIFICATION_TYPE_INGEST_FAILED", queuev1.NotificationType(IngestFailedNotification).String())
+		assert.Len(t, queuev1.NotificationType_name, int(IngestFailedNotification)+1)
+		assert.Equal(t, "NODE_STATE_DRAINING", queuev1.NodeState(DrainingState).String())
+		assert.Len(t, queuev1.NodeState_name, int(DrainingState)+1)
+		assert.Equal(t, "HEARTBEAT_TYPE_DEREGISTER", queuev1.HeartbeatType(DeregisterHeartbeat).String())
+		assert.Len(t, queuev1.HeartbeatType_name, int(DeregisterHeartbeat)+1)
+	})
+}
@@ -0,0 +1,227 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/google/uuid"
+	stdAmqp "github.com/rabbitmq/amqp091-go"
+	"strconv"
+	"time"
+)
+
+// Metadata of dead-lettered messages, it is published as headers of the transport.
+const (
+	DeadLetterReasonKey   = "dead_letter_reason"
+	DeadLetterAttemptsKey = "dead_letter_attempts"
+	DeadLetterTimeKey     = "dead_letter_time"
+	// messageUUIDKey is the header watermill keeps the message uuid in
+	messageUUIDKey = "_watermill_message_uuid"
+)
+
+// DeadLetter describes a message the node has given up on.
+type DeadLetter struct {
+	MessageUUID string      `json:"messageUuid"`
+	RequestID   uuid.UUID   `json:"requestId"` // zero if the payload can't be decoded
+	Type        RequestType `json:"type"`
+	FileID      uuid.UUID   `json:"fileId"`
+	Reason      string      `json:"reason"`
+	Attempts    int         `json:"attempts"`
+	DeadAt      time.Time   `json:"deadAt"`
+}
+
+var ErrDeadLettersUnsupported = errors.New("dead letters can't be browsed with this transport")
+
+// deadLetters routes messages which are poison or fail maxAttempts times to the dead-letter queue.
+type deadLetters struct {
+	pub   message.Publisher
+	queue string
+	// retryTopic gets the failed messages back to the node, their attempts are counted by DeadLetterAttemptsKey
+	retryTopic  string
+	maxAttempts int
+	// browser lists and replays dead letters, it is nil if the transport can't do it
+	browser deadLetterBrowser
+}
+
+// deadLetterBrowser inspects the dead-letter queue of the transport.
+type deadLetterBrowser interface {
+	List(ctx context.Context, limit int) ([]DeadLetter, error)
+	Replay(ctx context.Context, limit int) (int, error)
+}
+
+func newDeadLetters(pub message.Publisher, queue, retryTopic string, browser deadLetterBrowser, maxAttempts int) *deadLetters {
+	return &deadLetters{
+		pub:         pub,
+		queue:       queue,
+		retryTopic:  retryTopic,
+		maxAttempts: max(maxAttempts, 1),
+		browser:     browser,
+	}
+}
+
+// reject requeues the failed message, or dead-letters it once it is poison or has failed maxAttempts times.
+func (d *deadLetters) reject(msg *message.Message, reason error, poison bool) error {
+	attempts, _ := strconv.Atoi(msg.Metadata.Get(DeadLetterAttemptsKey))
+	attempts++
+	dead := poison || attempts >= d.maxAttempts
+
+	rejected := message.NewMessage(msg.UUID, msg.Payload)
+	for k, v := range msg.Metadata {
+		rejected.Metadata.Set(k, v)
+	}
+	rejected.Metadata.Set(DeadLetterAttemptsKey, strconv.Itoa(attempts))
+
+	topic := d.retryTopic
+	if dead {
+		topic = d.queue
+		rejected.Metadata.Set(DeadLetterReasonKey, reason.Error())
+		rejected.Metadata.Set(DeadLetterTimeKey, time.Now().UTC().Format(time.RFC3339))
+	}
+
+	if err := d.pub.Publish(topic, rejected); err != nil {
+		msg.Nack()
+		return fmt.Errorf("unable to reject message: %w", err)
+	}
+	msg.Ack()
+
+	return nil
+}
+
+// List returns up to limit dead-lettered messages, leaving them in the queue.
+func (d *deadLetters) List(ctx context.Context, limit int) ([]DeadLetter, error) {
+	if d.browser == nil {
+		return nil, ErrDeadLettersUnsupported
+	}
+
+	return d.browser.List(ctx, limit)
+}
+
+// Replay moves up to limit dead-lettered messages back to the queue of the node, returning how many have been moved.
+func (d *deadLetters) Replay(ctx context.Context, limit int) (int, error) {
+	if d.browser == nil {
+		return 0, ErrDeadLettersUnsupported
+	}
+
+	return d.browser.Replay(ctx, limit)
+}
+
+// amqpBrowser gets dead letters from the queue one by one and replays them to the source queue.
+type amqpBrowser struct {
+	urn    string
+	queue  string
+	source string
+}
+
+func (b *amqpBrowser) List(ctx context.Context, limit int) ([]DeadLetter, error) {
+	var deadLetters []DeadLetter
+	// unacknowledged messages are requeued once the channel is closed
+	err := b.consume(ctx, limit, func(_ *stdAmqp.Channel, delivery stdAmqp.Delivery) error {
+		deadLetters = append(deadLetters, deadLetter(delivery))
+		return nil
+	})
+
+	return deadLetters, err
+}
+
+func (b *amqpBrowser) Replay(ctx context.Context, limit int) (int, error) {
+	var replayed int
+	err := b.consume(ctx, limit, func(ch *stdAmqp.Channel, delivery stdAmqp.Delivery) error {
+		err := ch.PublishWithContext(ctx, "", b.source, false, false, stdAmqp.Publishing{
+			Headers:      replayHeaders(delivery.Headers),
+			ContentType:  delivery.ContentType,
+			DeliveryMode: delivery.DeliveryMode,
+			Body:         delivery.Body,
+		})
+		if err != nil {
+			return err
+		}
+		if err := delivery.Ack(false); err != nil {
+			return err
+		}
+		replayed++
+
+		return nil
+	})
+
+	return replayed, err
+}
+
+func (b *amqpBrowser) consume(ctx context.Context, limit int, fn func(*stdAmqp.Channel, stdAmqp.Delivery) error) error {
+	conn, err := stdAmqp.Dial(b.urn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	// declared the same way as by the publisher, in case nothing has been dead-lettered yet
+	if _, err := ch.QueueDeclare(b.queue, true, false, false, false, nil); err != nil {
+		return err
+	}
+
+	for range limit {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		delivery, ok, err := ch.Get(b.queue, false)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if err := fn(ch, delivery); err != nil {
+			return errors.Join(err, delivery.Nack(false, true))
+		}
+	}
+
+	return nil
+}
+
+func deadLetter(delivery stdAmqp.Delivery) DeadLetter {
+	deadLetter := DeadLetter{
+		MessageUUID: header(delivery.Headers, messageUUIDKey),
+		Reason:      header(delivery.Headers, DeadLetterReasonKey),
+	}
+	deadLetter.Attempts, _ = strconv.Atoi(header(delivery.Headers, DeadLetterAttemptsKey))
+	deadLetter.DeadAt, _ = time.Parse(time.RFC3339, header(delivery.Headers, DeadLetterTimeKey))
+
+	var request Request
+	if err := decodeRequest(&message.Message{Payload: delivery.Body, Metadata: headerMetadata(delivery.Headers)}, &request); err == nil {
+		deadLetter.RequestID, deadLetter.Type, deadLetter.FileID = request.ID, request.Type, request.FileID
+	}
+
+	return deadLetter
+}
+
+// replayHeaders copies the headers of the dead letter without the dead-letter ones.
+func replayHeaders(headers stdAmqp.Table) stdAmqp.Table {
+	replayed := make(stdAmqp.Table, len(headers))
+	for k, v := range headers {
+		replayed[k] = v
+	}
+	delete(replayed, DeadLetterReasonKey)
+	delete(replayed, DeadLetterAttemptsKey)
+	delete(replayed, DeadLetterTimeKey)
+
+	return replayed
+}
+
+func header(headers stdAmqp.Table, key string) string {
+	value, _ := headers[key].(string)
+	return value
+}
+
+func headerMetadata(headers stdAmqp.Table) message.Metadata {
+	return message.Metadata{
+		ContentTypeKey:   header(headers, ContentTypeKey),
+		SchemaVersionKey: header(headers, SchemaVersionKey),
+	}
+}
@@ -0,0 +1,172 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/google/uuid"
+	stdAmqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type fakeBrowser struct {
+	deadLetters []DeadLetter
+	replayed    int
+}
+
+func (b *fakeBrowser) List(_ context.Context, limit int) ([]DeadLetter, error) {
+	return b.deadLetters[:min(limit, len(b.deadLetters))], nil
+}
+
+func (b *fakeBrowser) Replay(_ context.Context, limit int) (int, error) {
+	b.replayed = min(limit, len(b.deadLetters))
+	b.deadLetters = b.deadLetters[b.replayed:]
+
+	return b.replayed, nil
+}
+
+func TestDeadLetters_Reject(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	transport := NewGoChannelTransport(gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{}))
+	defer transport.Close()
+	deadLetters, err := transport.Subscriber.Subscribe(ctx, "fs_1.dead-letters")
+	require.NoError(t, err)
+	retries, err := transport.Subscriber.Subscribe(ctx, "fs_1.retry")
+	require.NoError(t, err)
+
+	d := newDeadLetters(transport.Publisher, "fs_1.dead-letters", "fs_1.retry", transport.deadLetters, 3)
+	newMessage := func(id string) *message.Message {
+		msg := message.NewMessage(id, []byte("payload"))
+		msg.Metadata.Set(ContentTypeKey, JSONContentType)
+		return msg
+	}
+
+	t.Run("poison message is dead-lettered right away", func(t *testing.T) {
+		msg := newMessage(uuid.NewString())
+		require.NoError(t, d.reject(msg, errors.New("undecodable"), true))
+		assertClosed(t, msg.Acked(), "poison message must be acked once dead-lettered")
+
+		deadLetter := receive(t, deadLetters)
+		assert.Equal(t, msg.UUID, deadLetter.UUID)
+		assert.Equal(t, msg.Payload, deadLetter.Payload)
+		assert.Equal(t, "undecodable", deadLetter.Metadata.Get(DeadLetterReasonKey))
+		assert.Equal(t, "1", deadLetter.Metadata.Get(DeadLetterAttemptsKey))
+		assert.Equal(t, JSONContentType, deadLetter.Metadata.Get(ContentTypeKey), "metadata of the message is kept")
+		deadAt, err := time.Parse(time.RFC3339, deadLetter.Metadata.Get(DeadLetterTimeKey))
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), deadAt, time.Minute)
+	})
+
+	t.Run("failing message is dead-lettered after max attempts", func(t *testing.T) {
+		msg := newMessage(uuid.NewString())
+		for attempt := 1; attempt < 3; attempt++ {
+			require.NoError(t, d.reject(msg, errors.New("boom"), false))
+			assertClosed(t, msg.Acked(), "requeued message must be acked, attempt "+strconv.Itoa(attempt))
+
+			retried := receive(t, retries)
+			assert.Equal(t, msg.UUID, retried.UUID)
+			assert.Equal(t, msg.Payload, retried.Payload)
+			assert.Equal(t, strconv.Itoa(attempt), retried.Metadata.Get(DeadLetterAttemptsKey))
+			assert.Empty(t, retried.Metadata.Get(DeadLetterReasonKey))
+			retried.Ack()
+			msg = retried
+		}
+		select {
+		case msg := <-deadLetters:
+			t.Fatalf("message %s has been dead-lettered too early", msg.UUID)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		require.NoError(t, d.reject(msg, errors.New("boom"), false))
+		assertClosed(t, msg.Acked(), "dead-lettered message must be acked")
+
+		deadLetter := receive(t, deadLetters)
+		assert.Equal(t, msg.UUID, deadLetter.UUID)
+		assert.Equal(t, "boom", deadLetter.Metadata.Get(DeadLetterReasonKey))
+		assert.Equal(t, "3", deadLetter.Metadata.Get(DeadLetterAttemptsKey))
+	})
+
+	t.Run("attempts are counted by the header", func(t *testing.T) {
+		msg := newMessage(uuid.NewString())
+		msg.Metadata.Set(DeadLetterAttemptsKey, "2")
+		require.NoError(t, d.reject(msg, errors.New("boom"), false))
+
+		deadLetter := receive(t, deadLetters)
+		assert.Equal(t, msg.UUID, deadLetter.UUID, "attempts made before a restart count toward max attempts")
+		assert.Equal(t, "3", deadLetter.Metadata.Get(DeadLetterAttemptsKey))
+	})
+}
+
+func TestDeadLetters_Browse(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := newDeadLetters(nil, "", "", nil, 1).List(ctx, 10)
+	assert.ErrorIs(t, err, ErrDeadLettersUnsupported)
+	_, err = newDeadLetters(nil, "", "", nil, 1).Replay(ctx, 10)
+	assert.ErrorIs(t, err, ErrDeadLettersUnsupported)
+
+	browser := &fakeBrowser{deadLetters: []DeadLetter{{MessageUUID: "1"}, {MessageUUID: "2"}, {MessageUUID: "3"}}}
+	d := newDeadLetters(nil, "", "", browser, 1)
+
+	listed, err := d.List(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []DeadLetter{{MessageUUID: "1"}, {MessageUUID: "2"}}, listed)
+
+	replayed, err := d.Replay(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, replayed)
+	listed, err = d.List(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []DeadLetter{{MessageUUID: "3"}}, listed)
+}
+
+func TestDeadLetter_Headers(t *testing.T) {
+	request := Request{ID: uuid.New(), Type: DeleteType, FileID: uuid.New()}
+	payload, err := jsonCodec{}.Marshal(&request)
+	require.NoError(t, err)
+	deadAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	headers := stdAmqp.Table{
+		messageUUIDKey:        "message",
+		ContentTypeKey:        JSONContentType,
+		SchemaVersionKey:      SchemaVersion,
+		DeadLetterReasonKey:   "boom",
+		DeadLetterAttemptsKey: "3",
+		DeadLetterTimeKey:     deadAt.Format(time.RFC3339),
+	}
+
+	assert.Equal(t, DeadLetter{
+		MessageUUID: "message",
+		RequestID:   request.ID,
+		Type:        DeleteType,
+		FileID:      request.FileID,
+		Reason:      "boom",
+		Attempts:    3,
+		DeadAt:      deadAt,
+	}, deadLetter(stdAmqp.Delivery{Headers: headers, Body: payload}))
+
+	replayed := replayHeaders(headers)
+	assert.Equal(t, stdAmqp.Table{
+		messageUUIDKey:   "message",
+		ContentTypeKey:   JSONContentType,
+		SchemaVersionKey: SchemaVersion,
+	}, replayed, "replayed message must not carry the dead-letter headers")
+	assert.Equal(t, "boom", headers[DeadLetterReasonKey], "headers of the dead letter are left as they are")
+}
+
+func assertClosed(t *testing.T, ch <-chan struct{}, msg string) {
+	t.Helper()
+
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Error(msg)
+	}
+}
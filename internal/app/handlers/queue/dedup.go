@@ -0,0 +1,221 @@
+package queue
+
+import (
+	"bufio"
+	"container/list"
+	"encoding/binary"
+	"errors"
+	"github.com/google/uuid"
+	"io"
+	"os"
+	"sync"
+)
+
+// dedupRecord is appended to the journal of the store, a nil Response forgets the request.
+type dedupRecord struct {
+	ID       uuid.UUID
+	Response *Response
+}
+
+// dedup keeps responses of the last capacity requests to answer redelivered ones, journaling them to path.
+type dedup struct {
+	capacity  int
+	path      string
+	journal   *os.File
+	records   int
+	responses map[uuid.UUID]*list.Element
+	order     *list.List
+	inFlight  map[uuid.UUID]struct{}
+	mx        sync.Mutex
+	g         GobMarshaler
+}
+
+func newDedup(path string, capacity int) (*dedup, error) {
+	d := &dedup{
+		capacity:  max(capacity, 1),
+		path:      path,
+		responses: make(map[uuid.UUID]*list.Element),
+		order:     list.New(),
+		inFlight:  make(map[uuid.UUID]struct{}),
+	}
+	if path == "" {
+		return d, nil
+	}
+
+	if err := d.load(); err != nil {
+		return nil, err
+	}
+	if err := d.compact(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// claim reports whether the request has to be processed, otherwise it returns the cached response.
+func (d *dedup) claim(id uuid.UUID) (cached *Response, claimed bool) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	if e, ok := d.responses[id]; ok {
+		d.order.MoveToFront(e)
+		return e.Value.(*dedupRecord).Response, false
+	}
+	if _, ok := d.inFlight[id]; ok {
+		return nil, false
+	}
+	d.inFlight[id] = struct{}{}
+
+	return nil, true
+}
+
+// complete releases the claimed request and caches its response, requests without one are processed again.
+func (d *dedup) complete(id uuid.UUID, response *Response) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	delete(d.inFlight, id)
+	if response == nil {
+		return nil
+	}
+	d.put(id, response)
+
+	return d.append(&dedupRecord{ID: id, Response: response})
+}
+
+// forget drops the response of the request that has been reverted, so it is processed again.
+func (d *dedup) forget(id uuid.UUID) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	delete(d.inFlight, id)
+	e, ok := d.responses[id]
+	if !ok {
+		return nil
+	}
+	d.order.Remove(e)
+	delete(d.responses, id)
+
+	return d.append(&dedupRecord{ID: id})
+}
+
+func (d *dedup) put(id uuid.UUID, response *Response) {
+	if e, ok := d.responses[id]; ok {
+		e.Value.(*dedupRecord).Response = response
+		d.order.MoveToFront(e)
+		return
+	}
+
+	d.responses[id] = d.order.PushFront(&dedupRecord{ID: id, Response: response})
+	if d.order.Len() > d.capacity {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.responses, oldest.Value.(*dedupRecord).ID)
+	}
+}
+
+func (d *dedup) load() error {
+	f, err := os.Open(d.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		var size uint32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			// a torn record is left by a crash in the middle of an append
+			return nil
+		}
+		raw := make([]byte, size)
+		if _, err := io.ReadFull(r, raw); err != nil {
+			return nil
+		}
+
+		var record dedupRecord
+		if err := d.g.Unmarshal(raw, &record); err != nil {
+			return nil
+		}
+		if record.Response != nil {
+			d.put(record.ID, record.Response)
+		} else if e, ok := d.responses[record.ID]; ok {
+			d.order.Remove(e)
+			delete(d.responses, record.ID)
+		}
+	}
+}
+
+func (d *dedup) append(record *dedupRecord) error {
+	if d.journal == nil {
+		return nil
+	}
+
+	if d.records >= 2*d.capacity {
+		return d.compact()
+	}
+	if err := d.write(d.journal, record); err != nil {
+		return err
+	}
+	d.records++
+
+	return nil
+}
+
+// compact rewrites the journal with the cached responses only, oldest first.
+func (d *dedup) compact() error {
+	tmp := d.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	for e := d.order.Back(); e != nil; e = e.Prev() {
+		if err := d.write(f, e.Value.(*dedupRecord)); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := os.Rename(tmp, d.path); err != nil {
+		f.Close()
+		return err
+	}
+
+	if d.journal != nil {
+		d.journal.Close()
+	}
+	d.journal, d.records = f, d.order.Len()
+
+	return nil
+}
+
+func (d *dedup) write(w io.Writer, record *dedupRecord) error {
+	raw, err := d.g.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	buf := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(raw)), uint32(len(raw)))
+	_, err = w.Write(append(buf, raw...))
+
+	return err
+}
+
+func (d *dedup) Close() error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	if d.journal == nil {
+		return nil
+	}
+	err := d.journal.Close()
+	d.journal = nil
+
+	return err
+}
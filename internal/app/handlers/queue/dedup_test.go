@@ -0,0 +1,43 @@
+package queue
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+func TestDedup_AnswersReplayedRequestsAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.dedup")
+	d, err := newDedup(path, 2)
+	require.NoError(t, err)
+
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	for _, id := range ids {
+		_, claimed := d.claim(id)
+		require.True(t, claimed)
+		_, claimed = d.claim(id)
+		require.False(t, claimed, "request in flight must not be claimed twice")
+		require.NoError(t, d.complete(id, &Response{ID: id, ConnectionID: id}))
+	}
+	require.NoError(t, d.forget(ids[2]))
+	rejected := uuid.New()
+	d.claim(rejected)
+	require.NoError(t, d.complete(rejected, nil))
+	require.NoError(t, d.Close())
+
+	d, err = newDedup(path, 2)
+	require.NoError(t, err)
+	defer d.Close()
+
+	_, claimed := d.claim(ids[0])
+	assert.True(t, claimed, "the oldest response must be evicted")
+	cached, claimed := d.claim(ids[1])
+	require.False(t, claimed)
+	assert.Equal(t, ids[1], cached.ConnectionID)
+	_, claimed = d.claim(ids[2])
+	assert.True(t, claimed, "forgotten request must be processed again")
+	_, claimed = d.claim(rejected)
+	assert.True(t, claimed, "request without response must be processed again")
+}
@@ -5,147 +5,481 @@ import (
 	"errors"
 	"fmt"
 	"github.com/StratuStore/file-storage/internal/app/controller"
+	"github.com/StratuStore/file-storage/internal/app/fsm"
+	"github.com/StratuStore/file-storage/internal/app/replication"
 	"github.com/StratuStore/file-storage/internal/app/usecases"
 	"github.com/StratuStore/file-storage/internal/libs/config"
-	"github.com/ThreeDotsLabs/watermill"
-	"github.com/ThreeDotsLabs/watermill-amqp/v3/pkg/amqp"
 	"github.com/ThreeDotsLabs/watermill/message"
-	"github.com/go-resty/resty/v2"
+	"github.com/google/uuid"
 	"log/slog"
-	"net/http"
 	"net/url"
+	"os"
 	"sync"
 )
 
-const fsmPath = "/communicate"
+// Pages of ListType hold defaultListLimit files unless the request asks for another limit, maxListLimit at most.
+const (
+	defaultListLimit = 1000
+	maxListLimit     = 10000
+)
+
+// ErrNotAddressed is returned for a TransferType request which is done by another node, so it's left unanswered.
+var ErrNotAddressed = errors.New("request is addressed to another node")
+
+const (
+	fsmPath             = "/communicate"
+	fsmNotificationPath = "/communicate/notification"
+	fsmHeartbeatPath    = "/communicate/heartbeat"
+)
 
 type Handler struct {
-	l        *slog.Logger
-	sub      *amqp.Subscriber
-	useCases *usecases.UseCases
-	ctrl     *controller.Controller
-	client   *resty.Client
-	topic    string
-	host     string
-	token    string
-	g        GobMarshaler
-}
-
-func New(l *slog.Logger, cfg *config.Config, uc *usecases.UseCases, ctrl *controller.Controller) (*Handler, error) {
-	sub, err := amqp.NewSubscriber(
-		amqp.NewNonDurablePubSubConfig(cfg.RabbitMQ.URN, func(topic string) string { return cfg.QueueName }),
-		watermill.NewSlogLogger(l.With(slog.String("module", "watermill-ampq"))),
-	)
+	l         *slog.Logger
+	transport *Transport
+	responder responder
+	dedup     *dedup
+	dead      *deadLetters
+	useCases  *usecases.UseCases
+	ctrl      *controller.Controller
+	fsm       *fsm.Client
+	topic     string
+	host      string
+	fsmHost   string
+	// codec encodes notifications and heartbeats as contentType
+	codec       Codec
+	contentType string
+	// batchConcurrency is the number of sub-requests of a batch processed at once
+	batchConcurrency int
+	// migrations outlive their requests, they are cancelled and awaited on stop
+	migrationsCtx    context.Context
+	cancelMigrations context.CancelFunc
+	migrations       sync.WaitGroup
+	migrationsMx     sync.Mutex
+	// migrating holds the ids of the files being migrated
+	migrating sync.Map
+}
+
+// New consumes the requests carried by transport, the handler owns the transport once it is created.
+func New(l *slog.Logger, cfg *config.Config, uc *usecases.UseCases, ctrl *controller.Controller, fsmClient *fsm.Client, transport *Transport) (*Handler, error) {
+	dedup, err := newDedup(cfg.DedupPath, cfg.DedupCapacity)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("unable to load deduplication store: %w", err)
 	}
 
+	codec, err := codecFor(cfg.FSM.ContentType)
+	if err != nil {
+		return nil, errors.Join(err, dedup.Close())
+	}
+
+	var r responder
+	switch cfg.ResponseMode {
+	case HTTPResponseMode:
+		r = &httpResponder{fsm: fsmClient}
+	case QueueResponseMode, AMQPResponseMode:
+		r = newPublisherResponder(transport.Publisher, cfg.ResponseTopic, cfg.RevertTimeout)
+	default:
+		return nil, errors.Join(fmt.Errorf("unknown response mode %q", cfg.ResponseMode), dedup.Close())
+	}
+
+	retryTopic := transport.retryTopic
+	if retryTopic == "" {
+		retryTopic = cfg.Queue.Topic
+	}
+	migrationsCtx, cancelMigrations := context.WithCancel(context.Background())
+
 	return &Handler{
-		l:        l.With("internal.app.handlers.queue"),
-		sub:      sub,
-		useCases: uc,
-		ctrl:     ctrl,
-		client:   resty.New(),
-		topic:    cfg.RabbitMQ.Topic,
-		host:     cfg.RabbitMQ.Host,
-		token:    cfg.Token,
-		g:        GobMarshaler{},
+		l:                l.With(slog.String("op", "internal.app.handlers.queue")),
+		transport:        transport,
+		responder:        r,
+		dedup:            dedup,
+		dead:             newDeadLetters(transport.Publisher, deadLetterQueue(cfg.Queue), retryTopic, transport.deadLetters, cfg.MaxAttempts),
+		useCases:         uc,
+		ctrl:             ctrl,
+		fsm:              fsmClient,
+		topic:            cfg.Queue.Topic,
+		host:             cfg.RabbitMQ.Host,
+		fsmHost:          cfg.FSM.Host,
+		codec:            codec,
+		contentType:      cfg.FSM.ContentType,
+		batchConcurrency: max(cfg.BatchConcurrency, 1),
+		migrationsCtx:    migrationsCtx,
+		cancelMigrations: cancelMigrations,
 	}, nil
 }
 
 func (h *Handler) Start(ctx context.Context) error {
-	ch, err := h.sub.Subscribe(ctx, h.topic)
-	if err != nil {
-		return err
-	}
+	l := h.l.With(slog.String("op", "Start"))
 
-	var wg sync.WaitGroup
+	topics := []string{h.topic}
+	if h.transport.subscribeRetry {
+		topics = append(topics, h.dead.retryTopic)
+	}
 
-	for msg := range ch {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			if err2 := h.handle(msg); err2 != nil {
-				err = errors.Join(err, err2)
+	// a worker gets the next message once the previous one is acked, so workers bound the concurrency
+	var subscriptions []<-chan *message.Message
+	for _, topic := range topics {
+		for range max(h.transport.Subscriptions, 1) {
+			ch, err := h.transport.Subscriber.Subscribe(ctx, topic)
+			if err != nil {
+				return err
 			}
-		}()
+			subscriptions = append(subscriptions, ch)
+		}
 	}
 
+	var wg sync.WaitGroup
+	for _, ch := range subscriptions {
+		for range max(h.transport.Workers, 1) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for msg := range ch {
+					if err := h.handle(msg); err != nil {
+						l.Debug("message has not been processed", slog.String("uuid", msg.UUID), slog.String("err", err.Error()))
+					}
+				}
+			}()
+		}
+	}
 	wg.Wait()
 
-	return err
+	return nil
 }
 
+// Stop cancels the running migrations and waits for them until ctx is done.
 func (h *Handler) Stop(ctx context.Context) error {
-	return h.sub.Close()
+	err := errors.Join(h.transport.Close(), h.stopMigrations(ctx))
+
+	return errors.Join(err, h.dedup.Close())
 }
 
-func (h *Handler) handle(msg *message.Message) error {
+func (h *Handler) stopMigrations(ctx context.Context) error {
+	// no migration is started once they are cancelled, so they can be awaited
+	h.migrationsMx.Lock()
+	h.cancelMigrations()
+	h.migrationsMx.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.migrations.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("migrations haven't stopped: %w", ctx.Err())
+	}
+}
+
+// migrate starts the migration of the file unless it is already being migrated, its result is reported by a notification.
+func (h *Handler) migrate(fileID uuid.UUID, targets []string) {
+	l := h.l.With(slog.String("op", "migrate"), slog.String("id", fileID.String()))
+
+	if _, migrating := h.migrating.LoadOrStore(fileID, struct{}{}); migrating {
+		l.Debug("file is already being migrated")
+		return
+	}
+
+	h.migrationsMx.Lock()
+	defer h.migrationsMx.Unlock()
+	if h.migrationsCtx.Err() != nil {
+		h.migrating.Delete(fileID)
+		l.Debug("migrations have been stopped")
+		return
+	}
+
+	h.migrations.Add(1)
+	go func() {
+		defer h.migrations.Done()
+		defer h.migrating.Delete(fileID)
+
+		if err := h.useCases.MigrateFile(h.migrationsCtx, fileID, targets); err != nil {
+			l.Error("unable to migrate file", slog.String("err", err.Error()))
+		}
+	}()
+}
+
+// DeadLetters returns up to limit dead-lettered messages without removing them.
+func (h *Handler) DeadLetters(ctx context.Context, limit int) ([]DeadLetter, error) {
+	return h.dead.List(ctx, limit)
+}
+
+// ReplayDeadLetters moves up to limit dead-lettered messages back to the queue of the node.
+func (h *Handler) ReplayDeadLetters(ctx context.Context, limit int) (int, error) {
+	return h.dead.Replay(ctx, limit)
+}
+
+func (h *Handler) handle(msg *message.Message) (err error) {
 	l := h.l.With(slog.String("op", "handle"))
 
 	var request Request
-	if err := h.g.Unmarshal(msg.Payload, &request); err != nil {
-		l.Error("unable to unmarshal queue payload using gob", slog.String("err", err.Error()))
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while processing message: %v", r)
+			l.Error("unable to process message", slog.String("err", err.Error()))
+
+			// the request is released to be processed once redelivered
+			_ = h.dedup.complete(request.ID, nil)
+			if err := h.dead.reject(msg, err, false); err != nil {
+				l.Error("unable to reject message", slog.String("err", err.Error()))
+			}
+		}
+	}()
+
+	if err := decodeRequest(msg, &request); err != nil {
+		l.Error("unable to decode queue payload", slog.String("err", err.Error()))
 
-		msg.Nack()
-		return fmt.Errorf("unable to unmarshal queue payload using gob: %w", err)
+		err = fmt.Errorf("unable to decode queue payload: %w", err)
+		// the payload never gets decoded, so the message is dead-lettered right away
+		if err := h.dead.reject(msg, err, true); err != nil {
+			l.Error("unable to reject message", slog.String("err", err.Error()))
+		}
+		return err
 	}
 
-	msg.Ack()
+	if request.Type == RevertType {
+		msg.Ack()
+		if !h.responder.revert(request.RevertID) {
+			l.Debug("nothing to revert", slog.String("id", request.RevertID.String()))
+		}
+		return nil
+	}
 
 	ctx := context.Background()
-	response, revert, err := h.processRequest(ctx, &request)
-	if err != nil {
-		return err
+	response, claimed := h.dedup.claim(request.ID)
+	if !claimed && response == nil {
+		l.Debug("request is already being processed", slog.String("id", request.ID.String()))
+
+		msg.Ack()
+		return nil
 	}
-	responseBody, err := h.g.Marshal(response)
-	if err != nil {
-		l.Error("unable to marshal responseBody", slog.String("err", err.Error()))
 
-		return err
+	if claimed {
+		var err error
+		response, err = h.processRequest(ctx, &request)
+		if err2 := h.dedup.complete(request.ID, response); err2 != nil {
+			l.Warn("unable to persist response", slog.String("err", err2.Error()))
+		}
+		if notServed(err) {
+			// the node has nothing to answer, another one serves the request
+			msg.Ack()
+			return err
+		}
+		if err != nil {
+			l.Error("unable to process request", slog.String("id", request.ID.String()), slog.String("err", err.Error()))
+
+			if err := h.dead.reject(msg, err, false); err != nil {
+				l.Error("unable to reject message", slog.String("err", err.Error()))
+			}
+			return err
+		}
+	} else {
+		l.Info("answering redelivered request with cached response", slog.String("id", request.ID.String()))
 	}
 
-	link, err := url.JoinPath(request.Host, fsmPath)
-	if err != nil {
-		l.Error("unable to join url path", slog.String("err", err.Error()))
+	revert := h.revertFunc(ctx, &request, response)
+	if err := h.responder.respond(ctx, &request, response, func() {
+		revert()
+		// the reverted request is processed again once redelivered
+		if err := h.dedup.forget(request.ID); err != nil {
+			l.Error("unable to forget reverted request", slog.String("err", err.Error()))
+		}
+	}); err != nil {
+		l.Error("unable to respond to fsm", slog.String("err", err.Error()))
 
+		if err := h.dead.reject(msg, err, false); err != nil {
+			l.Error("unable to reject message", slog.String("err", err.Error()))
+		}
 		return err
 	}
 
-	result, err := h.client.R().
-		SetContext(ctx).
-		SetAuthScheme("Bearer").
-		SetAuthToken(h.token).
-		SetBody(responseBody).
-		Post(link)
+	msg.Ack()
+	return nil
+}
+
+// notServed reports whether the request has failed because the node doesn't serve it, rather than while it was processed.
+func notServed(err error) bool {
+	return errors.Is(err, os.ErrNotExist) ||
+		errors.Is(err, ErrNotAddressed) ||
+		errors.Is(err, ErrNothingToServe) ||
+		errors.Is(err, controller.ErrDraining) ||
+		errors.Is(err, controller.ErrReadOnly) ||
+		errors.Is(err, controller.ErrMaxSizeExceeded) ||
+		errors.Is(err, controller.ErrQuotaExceeded)
+}
+
+// revertFunc returns the function reverting the processed request once the FSM chooses another node.
+func (h *Handler) revertFunc(ctx context.Context, r *Request, response *Response) func() {
+	l := h.l.With(slog.String("op", "revert"))
+
+	switch r.Type {
+	case CreateType:
+		return func() {
+			if err := h.useCases.DeleteFile(ctx, r.FileID); err != nil {
+				l.Error("unable to delete file", slog.String("err", err.Error()))
+			}
+		}
+	case OpenType:
+		return func() {
+			if err := h.useCases.Close(ctx, response.ConnectionID); err != nil {
+				l.Error("unable to close connection", slog.String("err", err.Error()))
+			}
+		}
+	case CopyType:
+		return func() {
+			if response.Err != "" {
+				return
+			}
+			if err := h.useCases.DeleteFile(ctx, r.CopyID); err != nil {
+				l.Error("unable to delete copy", slog.String("err", err.Error()))
+			}
+		}
+	case IngestType:
+		return func() {
+			if response.Err != "" {
+				return
+			}
+			if err := h.useCases.CancelIngest(ctx, r.FileID); err != nil {
+				l.Error("unable to cancel ingest", slog.String("err", err.Error()))
+			}
+		}
+	case TransferType:
+		return func() {
+			// the pushed copy belongs to the target, which is asked to delete it by the FSM
+			if r.Push || response.Err != "" {
+				return
+			}
+			if err := h.useCases.DeleteFile(ctx, r.FileID); err != nil {
+				l.Error("unable to delete transferred file", slog.String("err", err.Error()))
+			}
+		}
+	case BatchType:
+		items := make(map[uuid.UUID]*Request, len(r.Items))
+		for i := range r.Items {
+			items[r.Items[i].ID] = &r.Items[i]
+		}
+
+		var reverts []func()
+		for i := range response.Items {
+			if item, ok := items[response.Items[i].ID]; ok {
+				reverts = append(reverts, h.revertFunc(ctx, item, &response.Items[i]))
+			}
+		}
+		return func() {
+			for _, revert := range reverts {
+				revert()
+			}
+		}
+	default:
+		return func() {}
+	}
+}
+
+// Notify sends the notification to the FSM.
+func (h *Handler) Notify(ctx context.Context, notification *Notification) error {
+	notification.ID = uuid.New()
+	notification.Host = h.host
+
+	return h.post(ctx, fsmNotificationPath, notification.ID, notification)
+}
+
+// post sends the encoded message to the FSM, the id of the message is its idempotency key.
+func (h *Handler) post(ctx context.Context, path string, id uuid.UUID, message any) error {
+	body, err := h.codec.Marshal(message)
 	if err != nil {
-		l.Error("unable to make request to fsm", slog.String("err", err.Error()))
-		revert()
+		return err
+	}
 
+	link, err := url.JoinPath(h.fsmHost, path)
+	if err != nil {
 		return err
 	}
-	if result.StatusCode() == http.StatusResetContent {
-		revert()
+
+	_, err = h.fsm.Post(ctx, link, id.String(), h.contentType, body)
+
+	return err
+}
+
+// NotifyMigrated reports to the FSM the file moved to the peers, or migrateErr if it has not been moved.
+func (h *Handler) NotifyMigrated(ctx context.Context, fileID uuid.UUID, statuses []replication.Status, migrateErr error) error {
+	notification := &Notification{
+		Type:     MigratedNotification,
+		FileIDs:  []uuid.UUID{fileID},
+		Replicas: make([]ReplicaStatus, len(statuses)),
+	}
+	for i, status := range statuses {
+		notification.Replicas[i] = ReplicaStatus(status)
+	}
+	if migrateErr != nil {
+		notification.Type = MigrationFailedNotification
+		notification.Err = migrateErr.Error()
 	}
 
-	return nil
+	return h.Notify(ctx, notification)
+}
+
+// NotifyIngested reports to the FSM the ingested file, or ingestErr if it has not been ingested.
+func (h *Handler) NotifyIngested(ctx context.Context, fileID uuid.UUID, size int64, checksum string, ingestErr error) error {
+	notification := &Notification{
+		Type:     IngestedNotification,
+		FileIDs:  []uuid.UUID{fileID},
+		Size:     size,
+		Checksum: checksum,
+	}
+	if ingestErr != nil {
+		notification = &Notification{Type: IngestFailedNotification, FileIDs: []uuid.UUID{fileID}, Err: ingestErr.Error()}
+	}
+
+	return h.Notify(ctx, notification)
 }
 
-func (h *Handler) processRequest(ctx context.Context, r *Request) (response *Response, revert func(), _ error) {
+// NotifyDraining reports to the FSM that the node has started or stopped draining.
+func (h *Handler) NotifyDraining(ctx context.Context, draining bool, fileIDs []uuid.UUID) error {
+	notification := &Notification{Type: ActiveNotification}
+	if draining {
+		notification = &Notification{Type: DrainingNotification, FileIDs: fileIDs}
+	}
+
+	return h.Notify(ctx, notification)
+}
+
+// NotifyReplicated reports to the FSM which peers have acknowledged replicas of the file.
+func (h *Handler) NotifyReplicated(ctx context.Context, fileID uuid.UUID, statuses []replication.Status) error {
+	replicas := make([]ReplicaStatus, len(statuses))
+	for i, status := range statuses {
+		replicas[i] = ReplicaStatus(status)
+	}
+
+	return h.Notify(ctx, &Notification{
+		Type:     ReplicatedNotification,
+		FileIDs:  []uuid.UUID{fileID},
+		Replicas: replicas,
+	})
+}
+
+func (h *Handler) processRequest(ctx context.Context, r *Request) (response *Response, _ error) {
 	l := h.l.With(slog.String("op", "processRequest"))
 
 	switch r.Type {
 	case CreateType:
-		if err := h.ctrl.TryAllocateStorage(int64(r.Size)); err != nil {
+		if h.ctrl.Draining() {
+			l.Info("rejecting request while draining")
+			return nil, controller.ErrDraining
+		}
+		if err := h.ctrl.TryAllocateStorage(int64(r.Size)); errors.Is(err, controller.ErrReadOnly) {
+			l.Warn("rejecting request in read-only mode", slog.String("err", err.Error()))
+			return nil, err
+		} else if err != nil {
 			l.Warn("we are full!")
-			return nil, nil, err
+			return nil, err
 		}
 
-		connectionID, err := h.useCases.CreateFile(ctx, r.Host, r.FileID)
+		connectionID, err := h.useCases.CreateFile(ctx, r.Host, r.FileID, int64(r.Size), r.OwnerID, r.ExpiresAt, r.Replicas)
 		if err != nil {
 			l.Error("unable to create file", slog.String("err", err.Error()))
 
-			return nil, nil, err
+			return nil, err
 		}
 		response = &Response{
 			ID:           r.ID,
@@ -153,23 +487,23 @@ func (h *Handler) processRequest(ctx context.Context, r *Request) (response *Res
 			ConnectionID: connectionID,
 			Err:          "",
 		}
-		revert = func() {
-			if err := h.useCases.DeleteFile(ctx, r.FileID); err != nil {
-				l.Error("unable to delete file", slog.String("err", err.Error()))
-			}
-		}
 	case UpdateType:
-		if err := h.ctrl.TryAllocateStorage(int64(r.Size)); err != nil {
-			l.Warn("we are full!")
-			return nil, nil, err
-		}
-		if _, err := h.ctrl.File(r.FileID); err != nil {
+		file, err := h.ctrl.File(r.FileID)
+		if err != nil {
 			l.Info("we have no such file", slog.String("err", err.Error()))
 
-			return nil, nil, err
+			return nil, err
+		}
+		// the reservation of the rewrite takes the current content over, so only the growth has to fit
+		if err := h.ctrl.TryAllocateStorage(max(int64(r.Size)-file.Size(), 0)); errors.Is(err, controller.ErrReadOnly) {
+			l.Warn("rejecting request in read-only mode", slog.String("err", err.Error()))
+			return nil, err
+		} else if err != nil {
+			l.Warn("we are full!")
+			return nil, err
 		}
 
-		connectionID, err := h.useCases.UpdateFile(ctx, r.Host, r.FileID)
+		connectionID, err := h.useCases.UpdateFile(ctx, r.Host, r.FileID, int64(r.Size), r.Replicas)
 		var errString string
 		if err != nil {
 			l.Error("unable to update file", slog.String("err", err.Error()))
@@ -182,12 +516,11 @@ func (h *Handler) processRequest(ctx context.Context, r *Request) (response *Res
 			ConnectionID: connectionID,
 			Err:          errString,
 		}
-		revert = func() {}
 	case OpenType:
 		if _, err := h.ctrl.File(r.FileID); err != nil {
 			l.Info("we have no such file", slog.String("err", err.Error()))
 
-			return nil, nil, err
+			return nil, err
 		}
 
 		connectionID, err := h.useCases.OpenFile(ctx, r.FileID)
@@ -203,16 +536,11 @@ func (h *Handler) processRequest(ctx context.Context, r *Request) (response *Res
 			ConnectionID: connectionID,
 			Err:          errString,
 		}
-		revert = func() {
-			if err := h.useCases.Close(ctx, connectionID); err != nil {
-				l.Error("unable to close connection", slog.String("err", err.Error()))
-			}
-		}
 	case DeleteType:
 		if _, err := h.ctrl.File(r.FileID); err != nil {
 			l.Info("we have no such file", slog.String("err", err.Error()))
 
-			return nil, nil, err
+			return nil, err
 		}
 
 		err := h.useCases.DeleteFile(ctx, r.FileID)
@@ -227,10 +555,203 @@ func (h *Handler) processRequest(ctx context.Context, r *Request) (response *Res
 			Host: h.host,
 			Err:  errString,
 		}
-		revert = func() {}
+	case QuotaType:
+		err := h.ctrl.SetQuota(r.OwnerID, controller.Quota{MaxBytes: int64(r.QuotaBytes), MaxFiles: int64(r.QuotaFiles)})
+		var errString string
+		if err != nil {
+			l.Error("unable to set quota", slog.String("err", err.Error()))
+
+			errString = err.Error()
+		}
+		response = &Response{
+			ID:   r.ID,
+			Host: h.host,
+			Err:  errString,
+		}
+	case MigrateType:
+		if _, err := h.ctrl.File(r.FileID); err != nil {
+			l.Info("we have no such file", slog.String("err", err.Error()))
+
+			return nil, err
+		}
+
+		// the migration outlives the request, its result is reported by a notification
+		h.migrate(r.FileID, r.Replicas)
+		response = &Response{
+			ID:   r.ID,
+			Host: h.host,
+		}
+	case UsageType:
+		usage := h.ctrl.OwnersUsage()
+		if r.OwnerID != "" {
+			ownerUsage, err := h.ctrl.OwnerUsage(r.OwnerID)
+			if err != nil {
+				l.Info("we have no files of such owner", slog.String("err", err.Error()))
+
+				return nil, err
+			}
+			usage = []controller.OwnerUsage{ownerUsage}
+		}
+
+		response = &Response{
+			ID:    r.ID,
+			Host:  h.host,
+			Usage: make([]OwnerUsage, 0, len(usage)),
+		}
+		for _, u := range usage {
+			response.Usage = append(response.Usage, OwnerUsage{
+				OwnerID:    u.OwnerID,
+				Used:       u.Used,
+				Reserved:   u.Reserved,
+				Files:      u.Files,
+				QuotaBytes: u.Quota.MaxBytes,
+				QuotaFiles: u.Quota.MaxFiles,
+			})
+		}
+	case CopyType:
+		if h.ctrl.Draining() {
+			l.Info("rejecting request while draining")
+			return nil, controller.ErrDraining
+		}
+		if _, err := h.ctrl.File(r.FileID); err != nil {
+			l.Info("we have no such file", slog.String("err", err.Error()))
+
+			return nil, err
+		}
+
+		size, checksum, err := h.useCases.CopyFile(ctx, r.FileID, r.CopyID, r.OwnerID, r.ExpiresAt)
+		var errString string
+		if err != nil {
+			l.Error("unable to copy file", slog.String("err", err.Error()))
+
+			errString = err.Error()
+		}
+		response = &Response{
+			ID:       r.ID,
+			Host:     h.host,
+			Err:      errString,
+			Size:     size,
+			Checksum: checksum,
+		}
+	case StatType:
+		info, err := h.useCases.StatFile(ctx, r.FileID)
+		if err != nil {
+			l.Info("we have no such file", slog.String("err", err.Error()))
+
+			return nil, err
+		}
+
+		metadata := info.Metadata
+		response = &Response{
+			ID:   r.ID,
+			Host: h.host,
+			Files: []FileInfo{{
+				FileID:       info.ID,
+				Size:         info.Size,
+				Checksum:     metadata.Checksum,
+				Version:      metadata.Version,
+				OwnerID:      metadata.OwnerID,
+				AccessedAt:   metadata.AccessedAt,
+				ModifiedAt:   metadata.ModifiedAt,
+				ExpiresAt:    metadata.ExpiresAt,
+				Replicas:     metadata.Replicas,
+				ErasureCoded: metadata.Erasure != nil,
+			}},
+		}
+	case ListType:
+		response = &Response{
+			ID:   r.ID,
+			Host: h.host,
+		}
+
+		var after uuid.UUID
+		if r.Cursor != "" {
+			var err error
+			if after, err = uuid.Parse(r.Cursor); err != nil {
+				response.Err = fmt.Sprintf("invalid cursor %q", r.Cursor)
+				break
+			}
+		}
+
+		limit := defaultListLimit
+		if r.Limit > 0 {
+			limit = min(int(r.Limit), maxListLimit)
+		}
+		files, more, err := h.useCases.ListFiles(ctx, after, limit)
+		if err != nil {
+			l.Error("unable to list files", slog.String("err", err.Error()))
+
+			response.Err = err.Error()
+			break
+		}
+
+		response.Files = make([]FileInfo, len(files))
+		for i, file := range files {
+			response.Files[i] = FileInfo{FileID: file.ID, Size: file.Size}
+		}
+		if more {
+			response.Cursor = files[len(files)-1].ID.String()
+		}
+	case TransferType:
+		transfer, peer := h.useCases.PullFile, r.Source
+		if r.Push {
+			transfer, peer = h.useCases.PushFile, r.Target
+		}
+		if (r.Push && r.Source != h.host) || (!r.Push && r.Target != h.host) {
+			return nil, ErrNotAddressed
+		}
+		if !r.Push && h.ctrl.Draining() {
+			l.Info("rejecting request while draining")
+			return nil, controller.ErrDraining
+		}
+
+		size, checksum, err := transfer(ctx, r.FileID, peer)
+		var errString string
+		if err != nil {
+			l.Error("unable to transfer file", slog.String("host", peer), slog.String("err", err.Error()))
+
+			errString = err.Error()
+		}
+		response = &Response{
+			ID:       r.ID,
+			Host:     h.host,
+			Err:      errString,
+			Size:     size,
+			Checksum: checksum,
+		}
+	case IngestType:
+		if r.Target != "" && r.Target != h.host {
+			return nil, ErrNotAddressed
+		}
+		if h.ctrl.Draining() {
+			l.Info("rejecting request while draining")
+			return nil, controller.ErrDraining
+		}
+		if err := h.ctrl.TryAllocateStorage(int64(r.Size)); errors.Is(err, controller.ErrReadOnly) {
+			l.Warn("rejecting request in read-only mode", slog.String("err", err.Error()))
+			return nil, err
+		} else if err != nil {
+			l.Warn("we are full!")
+			return nil, err
+		}
+
+		err := h.useCases.IngestFile(ctx, r.FileID, r.Source, int64(r.Size), r.OwnerID, r.ExpiresAt, r.Replicas)
+		var errString string
+		if err != nil {
+			l.Error("unable to ingest file", slog.String("err", err.Error()))
+
+			errString = err.Error()
+		}
+		response = &Response{
+			ID:   r.ID,
+			Host: h.host,
+			Err:  errString,
+		}
+	case BatchType:
+		return h.processBatch(ctx, r)
 	default:
-		return nil, nil, fmt.Errorf("wrong request type")
+		return nil, fmt.Errorf("wrong request type")
 	}
 
-	return response, revert, nil
+	return response, nil
 }
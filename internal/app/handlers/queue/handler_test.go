@@ -0,0 +1,191 @@
+package queue
+
+import (
+	"context"
+	"github.com/StratuStore/file-storage/internal/app/controller"
+	"github.com/StratuStore/file-storage/internal/app/fileio"
+	"github.com/StratuStore/file-storage/internal/app/replication"
+	"github.com/StratuStore/file-storage/internal/app/usecases"
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestHandler(t *testing.T) *Handler {
+	layout, err := controller.NewLayout(0, 0)
+	require.NoError(t, err)
+	placement, err := controller.NewPlacement(controller.FreeSpacePlacement)
+	require.NoError(t, err)
+	ctrl, err := controller.NewController([]*controller.Disk{controller.NewDisk(t.TempDir(), 1<<20)}, layout, placement, controller.Watermarks{Low: 1, High: 1})
+	require.NoError(t, err)
+
+	migrationsCtx, cancelMigrations := context.WithCancel(context.Background())
+	t.Cleanup(cancelMigrations)
+
+	return &Handler{
+		l:                slog.Default(),
+		ctrl:             ctrl,
+		useCases:         usecases.NewUseCases(nil, nil, ctrl, nil, nil, slog.Default(), 512, 512, false),
+		host:             "http://fs-1:5000",
+		batchConcurrency: 2,
+		migrationsCtx:    migrationsCtx,
+		cancelMigrations: cancelMigrations,
+	}
+}
+
+func TestHandler_StatAndList(t *testing.T) {
+	h := newTestHandler(t)
+	ctx := context.Background()
+
+	ids := make(map[uuid.UUID]bool)
+	for range 5 {
+		file, reservation, err := h.ctrl.AddReservedFile(uuid.New(), 4, fileio.Metadata{OwnerID: "owner"})
+		require.NoError(t, err)
+		writer, err := file.Writer(reservation)
+		require.NoError(t, err)
+		_, err = writer.Write([]byte("file"))
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+		ids[file.ID()] = true
+	}
+
+	var cursor string
+	var pages int
+	listed := make(map[uuid.UUID]bool)
+	for {
+		response, err := h.processRequest(ctx, &Request{ID: uuid.New(), Type: ListType, Cursor: cursor, Limit: 2})
+		require.NoError(t, err)
+		require.Empty(t, response.Err)
+		for _, file := range response.Files {
+			assert.EqualValues(t, 4, file.Size)
+			listed[file.FileID] = true
+		}
+		pages++
+
+		if cursor = response.Cursor; cursor == "" {
+			break
+		}
+	}
+	assert.Equal(t, ids, listed)
+	assert.Equal(t, 3, pages)
+
+	id := h.ctrl.FileIDs()[0]
+	response, err := h.processRequest(ctx, &Request{ID: uuid.New(), Type: StatType, FileID: id})
+	require.NoError(t, err)
+	require.Len(t, response.Files, 1)
+	assert.Equal(t, id, response.Files[0].FileID)
+	assert.EqualValues(t, 1, response.Files[0].Version)
+	assert.Equal(t, "owner", response.Files[0].OwnerID)
+	assert.NotEmpty(t, response.Files[0].Checksum)
+	assert.False(t, response.Files[0].ModifiedAt.IsZero())
+
+	_, err = h.processRequest(ctx, &Request{ID: uuid.New(), Type: StatType, FileID: uuid.New()})
+	assert.Error(t, err, "files of other nodes must not be answered")
+}
+
+type blockingReplicator struct {
+	usecases.Replicator
+	moves atomic.Int32
+}
+
+func (r *blockingReplicator) Move(ctx context.Context, _ fileio.File, peers []string) []replication.Status {
+	r.moves.Add(1)
+	<-ctx.Done()
+
+	return []replication.Status{{Host: peers[0], Err: ctx.Err().Error()}}
+}
+
+type nopNotifier struct {
+	usecases.Notifier
+}
+
+func (nopNotifier) NotifyMigrated(context.Context, uuid.UUID, []replication.Status, error) error {
+	return nil
+}
+
+func TestHandler_MigrationsAreTrackedAndCancelledOnStop(t *testing.T) {
+	h := newTestHandler(t)
+	replicator := &blockingReplicator{}
+	h.useCases.Replicator = replicator
+	h.useCases.Notifier = nopNotifier{}
+	ctx := context.Background()
+
+	file, reservation, err := h.ctrl.AddReservedFile(uuid.New(), 4, fileio.Metadata{})
+	require.NoError(t, err)
+	writer, err := file.Writer(reservation)
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("file"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	migrate := &Request{ID: uuid.New(), Type: MigrateType, FileID: file.ID(), Replicas: []string{"http://fs-2:5000"}}
+	_, err = h.processRequest(ctx, migrate)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return replicator.moves.Load() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	_, err = h.processRequest(ctx, migrate)
+	require.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	assert.EqualValues(t, 1, replicator.moves.Load(), "file being migrated must not be migrated twice")
+
+	stopCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	require.NoError(t, h.stopMigrations(stopCtx), "stop must cancel and await the migration")
+
+	_, err = h.processRequest(ctx, migrate)
+	require.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	assert.EqualValues(t, 1, replicator.moves.Load(), "no migration is started once stopped")
+}
+
+func TestHandler_FailedRequestsAreRetried(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	transport := NewGoChannelTransport(gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{}))
+	defer transport.Close()
+	retries, err := transport.Subscriber.Subscribe(ctx, "fs_1.retry")
+	require.NoError(t, err)
+
+	h := newTestHandler(t)
+	h.dedup, err = newDedup("", 16)
+	require.NoError(t, err)
+	h.dead = newDeadLetters(transport.Publisher, "fs_1.dead-letters", "fs_1.retry", nil, 3)
+	newMessage := func(r *Request) *message.Message {
+		payload, err := jsonCodec{}.Marshal(r)
+		require.NoError(t, err)
+		msg := message.NewMessage(uuid.NewString(), payload)
+		msg.Metadata.Set(ContentTypeKey, JSONContentType)
+		return msg
+	}
+
+	file, reservation, err := h.ctrl.AddReservedFile(uuid.New(), 0, fileio.Metadata{})
+	require.NoError(t, err)
+	reservation.Release()
+
+	msg := newMessage(&Request{ID: uuid.New(), Type: CreateType, FileID: file.ID(), Size: 4})
+	assert.Error(t, h.handle(msg))
+	assertClosed(t, msg.Acked(), "failed message must be acked once requeued")
+	retried := receive(t, retries)
+	assert.Equal(t, msg.UUID, retried.UUID)
+	assert.Equal(t, "1", retried.Metadata.Get(DeadLetterAttemptsKey))
+
+	msg = newMessage(&Request{ID: uuid.New(), Type: StatType, FileID: uuid.New()})
+	assert.ErrorIs(t, h.handle(msg), os.ErrNotExist)
+	assertClosed(t, msg.Acked(), "message served by another node must be acked")
+	select {
+	case msg := <-retries:
+		t.Fatalf("message %s served by another node has been requeued", msg.UUID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
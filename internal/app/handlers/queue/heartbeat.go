@@ -0,0 +1,92 @@
+package queue
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"log/slog"
+	"runtime/debug"
+	"time"
+)
+
+// deregisterTimeout bounds the deregistration on stop, when the context of the node is already done.
+const deregisterTimeout = 5 * time.Second
+
+// StartHeartbeat registers the node in the FSM, reports its capacity every interval and deregisters it once ctx is done.
+func (h *Handler) StartHeartbeat(ctx context.Context, interval time.Duration) error {
+	l := h.l.With(slog.String("op", "StartHeartbeat"))
+
+	if err := h.sendHeartbeat(ctx, RegisterHeartbeat); err != nil {
+		l.Error("unable to register node in fsm", slog.String("err", err.Error()))
+	}
+
+	// a nil channel never fires, so only the deregistration is left when the heartbeats are disabled
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			ctx, cancel := context.WithTimeout(context.Background(), deregisterTimeout)
+			defer cancel()
+
+			if err := h.sendHeartbeat(ctx, DeregisterHeartbeat); err != nil {
+				l.Error("unable to deregister node in fsm", slog.String("err", err.Error()))
+			}
+			return nil
+		case <-tick:
+			if err := h.sendHeartbeat(ctx, PeriodicHeartbeat); err != nil {
+				l.Warn("unable to send heartbeat to fsm", slog.String("err", err.Error()))
+			}
+		}
+	}
+}
+
+func (h *Handler) sendHeartbeat(ctx context.Context, heartbeatType HeartbeatType) error {
+	heartbeat := h.heartbeat(heartbeatType)
+
+	return h.post(ctx, fsmHeartbeatPath, heartbeat.ID, heartbeat)
+}
+
+func (h *Handler) heartbeat(heartbeatType HeartbeatType) *Heartbeat {
+	state := ActiveState
+	switch {
+	case h.ctrl.Draining():
+		state = DrainingState
+	case h.ctrl.ReadOnly():
+		state = ReadOnlyState
+	}
+
+	return &Heartbeat{
+		ID:                uuid.New(),
+		Host:              h.host,
+		Type:              heartbeatType,
+		Version:           version(),
+		State:             state,
+		MaxSize:           h.ctrl.MaxSize,
+		CurrentSize:       h.ctrl.CurrentSize.Load(),
+		Reserved:          h.ctrl.Reserved(),
+		Free:              h.ctrl.Free(),
+		FileConnections:   h.useCases.FilesConnector.Len(),
+		ReaderConnections: h.useCases.ReadersConnector.Len(),
+		SentAt:            time.Now(),
+	}
+}
+
+// version returns the VCS revision the binary has been built from.
+func version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+
+	return info.Main.Version
+}
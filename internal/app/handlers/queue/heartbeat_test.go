@@ -0,0 +1,51 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/StratuStore/file-storage/internal/app/connector"
+	"github.com/StratuStore/file-storage/internal/app/fsm"
+	"github.com/StratuStore/file-storage/internal/app/usecases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_StartHeartbeat_Disabled(t *testing.T) {
+	heartbeats := make(chan HeartbeatType, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var heartbeat Heartbeat
+		if err := json.NewDecoder(r.Body).Decode(&heartbeat); err == nil {
+			heartbeats <- heartbeat.Type
+		}
+	}))
+	defer server.Close()
+
+	h := newTestHandler(t)
+	h.fsm = fsm.New(fsm.Options{Timeout: time.Second})
+	h.fsmHost = server.URL
+	h.codec = jsonCodec{}
+	h.contentType = "application/json"
+	h.useCases.FilesConnector = connector.NewConnector[*usecases.FileWithHost]()
+	h.useCases.ReadersConnector = connector.NewConnector[usecases.Reader]()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- h.StartHeartbeat(ctx, 0)
+	}()
+
+	assert.Equal(t, RegisterHeartbeat, <-heartbeats)
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "heartbeat hasn't stopped")
+	}
+	assert.Equal(t, DeregisterHeartbeat, <-heartbeats)
+	assert.Empty(t, heartbeats, "no periodic heartbeat is sent when they are disabled")
+}
@@ -0,0 +1,149 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"github.com/StratuStore/file-storage/internal/app/fsm"
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/google/uuid"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	HTTPResponseMode  = "http"
+	QueueResponseMode = "queue"
+	// AMQPResponseMode is the name QueueResponseMode had when AMQP was the only transport
+	AMQPResponseMode = "amqp"
+)
+
+// correlationIDKey is the metadata key of the published response which holds Request.ID.
+const correlationIDKey = "correlation_id"
+
+// responder delivers the response to the FSM, reverting the request if another node is chosen.
+type responder interface {
+	respond(ctx context.Context, request *Request, response *Response, revert func()) error
+	// revert reverts the request answered earlier, reporting whether it has been found.
+	revert(id uuid.UUID) bool
+}
+
+// httpResponder posts the response to the FSM which answers with 205 Reset Content to choose another node.
+type httpResponder struct {
+	fsm *fsm.Client
+}
+
+func (r *httpResponder) respond(ctx context.Context, request *Request, response *Response, revert func()) error {
+	body, contentType, err := encodeResponse(request, response)
+	if err != nil {
+		return err
+	}
+
+	link, err := url.JoinPath(request.Host, fsmPath)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.fsm.Post(ctx, link, request.ID.String(), contentType, body)
+	if err != nil {
+		revert()
+		return fmt.Errorf("unable to make request to fsm: %w", err)
+	}
+	if result.StatusCode() == http.StatusResetContent {
+		revert()
+	}
+
+	return nil
+}
+
+// revert does nothing, since requests answered over HTTP are reverted right away.
+func (r *httpResponder) revert(uuid.UUID) bool {
+	return false
+}
+
+// publisherResponder publishes the response to the reply topic of the request, or to the default one.
+type publisherResponder struct {
+	pub           message.Publisher
+	topic         string
+	revertTimeout time.Duration
+	reverts       map[uuid.UUID]*pendingRevert
+	mx            sync.Mutex
+}
+
+// pendingRevert is a revert kept until its timer forgets it.
+type pendingRevert struct {
+	revert func()
+	timer  *time.Timer
+}
+
+func newPublisherResponder(pub message.Publisher, topic string, revertTimeout time.Duration) *publisherResponder {
+	return &publisherResponder{
+		pub:           pub,
+		topic:         topic,
+		revertTimeout: revertTimeout,
+		reverts:       make(map[uuid.UUID]*pendingRevert),
+	}
+}
+
+func (r *publisherResponder) respond(_ context.Context, request *Request, response *Response, revert func()) error {
+	body, contentType, err := encodeResponse(request, response)
+	if err != nil {
+		return err
+	}
+
+	topic := r.topic
+	if request.ReplyTo != "" {
+		topic = request.ReplyTo
+	}
+
+	msg := message.NewMessage(watermill.NewUUID(), body)
+	msg.Metadata.Set(correlationIDKey, request.ID.String())
+	msg.Metadata.Set(ContentTypeKey, contentType)
+	msg.Metadata.Set(SchemaVersionKey, SchemaVersion)
+
+	// the revert must be known before the FSM can ask for it
+	r.keep(request.ID, revert)
+	if err := r.pub.Publish(topic, msg); err != nil {
+		r.revert(request.ID)
+		return fmt.Errorf("unable to publish response: %w", err)
+	}
+
+	return nil
+}
+
+// keep replaces the revert kept for the same request, the timer only forgets the revert it has been started for.
+func (r *publisherResponder) keep(id uuid.UUID, revert func()) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if previous, ok := r.reverts[id]; ok {
+		previous.timer.Stop()
+	}
+
+	pending := &pendingRevert{revert: revert}
+	pending.timer = time.AfterFunc(r.revertTimeout, func() {
+		r.mx.Lock()
+		defer r.mx.Unlock()
+
+		if r.reverts[id] == pending {
+			delete(r.reverts, id)
+		}
+	})
+	r.reverts[id] = pending
+}
+
+func (r *publisherResponder) revert(id uuid.UUID) bool {
+	r.mx.Lock()
+	pending, ok := r.reverts[id]
+	delete(r.reverts, id)
+	r.mx.Unlock()
+
+	if ok {
+		pending.timer.Stop()
+		pending.revert()
+	}
+
+	return ok
+}
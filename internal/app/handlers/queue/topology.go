@@ -0,0 +1,75 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"github.com/StratuStore/file-storage/internal/libs/config"
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill-amqp/v3/pkg/amqp"
+	stdAmqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	ClassicQueue = "classic"
+	QuorumQueue  = "quorum"
+)
+
+var ErrInvalidTopology = errors.New("invalid queue topology")
+
+// subscriberConfig binds the queue named queueName to the exchange named Topic with every routing key of the node.
+func subscriberConfig(cfg config.RabbitMQ, queueName string) (amqp.Config, error) {
+	c := amqp.NewNonDurablePubSubConfig(cfg.URN, func(string) string { return queueName })
+	if cfg.Durable {
+		c = amqp.NewDurablePubSubConfig(cfg.URN, func(string) string { return queueName })
+	}
+
+	switch cfg.QueueType {
+	case "", ClassicQueue:
+	case QuorumQueue:
+		if !cfg.Durable {
+			return amqp.Config{}, fmt.Errorf("%w: quorum queues must be durable", ErrInvalidTopology)
+		}
+		c.Queue.Arguments = stdAmqp.Table{stdAmqp.QueueTypeArg: stdAmqp.QueueTypeQuorum}
+	default:
+		return amqp.Config{}, fmt.Errorf("%w: unknown queue type %q", ErrInvalidTopology, cfg.QueueType)
+	}
+
+	switch cfg.ExchangeType {
+	case stdAmqp.ExchangeFanout, stdAmqp.ExchangeDirect, stdAmqp.ExchangeTopic:
+		c.Exchange.Type = cfg.ExchangeType
+	default:
+		return amqp.Config{}, fmt.Errorf("%w: unknown exchange type %q", ErrInvalidTopology, cfg.ExchangeType)
+	}
+	if cfg.ExchangeType != stdAmqp.ExchangeFanout && len(cfg.RoutingKeys) == 0 {
+		return amqp.Config{}, fmt.Errorf("%w: %s exchange needs routing keys", ErrInvalidTopology, cfg.ExchangeType)
+	}
+
+	if cfg.PrefetchCount < 1 {
+		return amqp.Config{}, fmt.Errorf("%w: prefetch count must be positive", ErrInvalidTopology)
+	}
+	c.Consume.Qos.PrefetchCount = cfg.PrefetchCount
+	c.TopologyBuilder = &topologyBuilder{routingKeys: cfg.RoutingKeys}
+
+	return c, nil
+}
+
+// topologyBuilder binds the queue with several routing keys, e.g. the id of the node and the one of its zone.
+type topologyBuilder struct {
+	amqp.DefaultTopologyBuilder
+	routingKeys []string
+}
+
+func (b *topologyBuilder) BuildTopology(channel *stdAmqp.Channel, params amqp.BuildTopologyParams, config amqp.Config, logger watermill.LoggerAdapter) error {
+	if len(b.routingKeys) == 0 {
+		return b.DefaultTopologyBuilder.BuildTopology(channel, params, config, logger)
+	}
+
+	for _, key := range b.routingKeys {
+		params.RoutingKey = key
+		if err := b.DefaultTopologyBuilder.BuildTopology(channel, params, config, logger); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
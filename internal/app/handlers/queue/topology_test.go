@@ -0,0 +1,39 @@
+package queue
+
+import (
+	"github.com/StratuStore/file-storage/internal/libs/config"
+	stdAmqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSubscriberConfig(t *testing.T) {
+	c, err := subscriberConfig(config.RabbitMQ{
+		Durable:       true,
+		QueueType:     QuorumQueue,
+		ExchangeType:  stdAmqp.ExchangeDirect,
+		RoutingKeys:   []string{"fs_1", "zone_a"},
+		PrefetchCount: 4,
+	}, "fs_1")
+	require.NoError(t, err)
+	assert.True(t, c.Queue.Durable)
+	assert.True(t, c.Exchange.Durable)
+	assert.Equal(t, stdAmqp.QueueTypeQuorum, c.Queue.Arguments[stdAmqp.QueueTypeArg])
+	assert.Equal(t, stdAmqp.ExchangeDirect, c.Exchange.Type)
+	assert.Equal(t, 4, c.Consume.Qos.PrefetchCount)
+	assert.Equal(t, "fs_1", c.Queue.GenerateName("fsm_to_fs"))
+
+	for name, cfg := range map[string]config.RabbitMQ{
+		"non-durable quorum queue":     {QueueType: QuorumQueue, ExchangeType: stdAmqp.ExchangeFanout, PrefetchCount: 1},
+		"unknown queue type":           {QueueType: "stream", ExchangeType: stdAmqp.ExchangeFanout, PrefetchCount: 1},
+		"unknown exchange type":        {ExchangeType: "x-delayed-message", PrefetchCount: 1},
+		"direct exchange without keys": {ExchangeType: stdAmqp.ExchangeDirect, PrefetchCount: 1},
+		"no prefetch":                  {ExchangeType: stdAmqp.ExchangeFanout},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := subscriberConfig(cfg, "fs_1")
+			assert.ErrorIs(t, err, ErrInvalidTopology)
+		})
+	}
+}
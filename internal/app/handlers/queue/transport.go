@@ -0,0 +1,168 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"github.com/StratuStore/file-storage/internal/libs/config"
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill-amqp/v3/pkg/amqp"
+	"github.com/ThreeDotsLabs/watermill-nats/v2/pkg/nats"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	stdAmqp "github.com/rabbitmq/amqp091-go"
+	"log/slog"
+)
+
+const (
+	AMQPTransport      = "amqp"
+	NATSTransport      = "nats"
+	GoChannelTransport = "gochannel"
+)
+
+var ErrUnknownTransport = errors.New("unknown queue transport")
+
+// Transport carries requests of the FSM to the node, and responses and dead letters back.
+type Transport struct {
+	Subscriber message.Subscriber
+	Publisher  message.Publisher
+	// Subscriptions to the topic are consumed by Workers goroutines each, one message at a time
+	Subscriptions int
+	Workers       int
+	// deadLetters browses dead letters if the transport can do it
+	deadLetters deadLetterBrowser
+	// retryTopic gets failed messages back to this node only, the node subscribes to it if subscribeRetry is set
+	retryTopic     string
+	subscribeRetry bool
+}
+
+// NewTransport connects to the transport chosen by cfg.Transport.
+func NewTransport(l *slog.Logger, cfg *config.Config) (*Transport, error) {
+	concurrency := max(cfg.Concurrency, 1)
+
+	switch cfg.Transport {
+	case AMQPTransport:
+		return newAMQPTransport(l, cfg.RabbitMQ, cfg.Queue, concurrency)
+	case NATSTransport:
+		return newNATSTransport(l, cfg.NATS, cfg.Queue, concurrency)
+	case GoChannelTransport:
+		pubSub := gochannel.NewGoChannel(gochannel.Config{OutputChannelBuffer: cfg.BufferSize}, watermillLogger(l, "watermill-gochannel"))
+		return NewGoChannelTransport(pubSub), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTransport, cfg.Transport)
+	}
+}
+
+// NewGoChannelTransport shares pubSub with the FSM running in the same process, requests are processed one at a time.
+func NewGoChannelTransport(pubSub *gochannel.GoChannel) *Transport {
+	return &Transport{
+		Subscriber:    pubSub,
+		Publisher:     pubSub,
+		Subscriptions: 1,
+		Workers:       1,
+	}
+}
+
+// newAMQPTransport consumes the queue with concurrency consumers.
+func newAMQPTransport(l *slog.Logger, cfg config.RabbitMQ, queue config.Queue, concurrency int) (*Transport, error) {
+	logger := watermillLogger(l, "watermill-ampq")
+
+	subConfig, err := subscriberConfig(cfg, queue.QueueName)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := amqp.NewSubscriber(subConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	pubConfig := amqp.NewDurableQueueConfig(cfg.URN)
+	pubConfig.Marshaler = amqp.DefaultMarshaler{
+		PostprocessPublishing: func(publishing stdAmqp.Publishing) stdAmqp.Publishing {
+			publishing.CorrelationId, _ = publishing.Headers[correlationIDKey].(string)
+			publishing.ContentType, _ = publishing.Headers[ContentTypeKey].(string)
+			return publishing
+		},
+	}
+	pub, err := amqp.NewPublisher(pubConfig, logger)
+	if err != nil {
+		return nil, errors.Join(err, sub.Close())
+	}
+
+	return &Transport{
+		Subscriber:    sub,
+		Publisher:     pub,
+		Subscriptions: concurrency,
+		Workers:       1,
+		deadLetters:   &amqpBrowser{urn: cfg.URN, queue: deadLetterQueue(queue), source: queue.QueueName},
+		// published to the default exchange, so they land in the queue of the node
+		retryTopic: queue.QueueName,
+	}, nil
+}
+
+// newNATSTransport joins the queue group named QueueName, so a request is delivered to one of its members.
+func newNATSTransport(l *slog.Logger, cfg config.NATS, queue config.Queue, concurrency int) (*Transport, error) {
+	logger := watermillLogger(l, "watermill-nats")
+	jetStream := nats.JetStreamConfig{
+		Disabled:      !cfg.JetStream,
+		AutoProvision: true,
+		DurablePrefix: queue.QueueName,
+	}
+
+	sub, err := nats.NewSubscriber(nats.SubscriberConfig{
+		URL:              cfg.Servers,
+		QueueGroupPrefix: queue.QueueName,
+		SubscribersCount: concurrency,
+		AckWaitTimeout:   cfg.AckWait,
+		Unmarshaler:      &nats.NATSMarshaler{},
+		JetStream:        jetStream,
+	}, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := nats.NewPublisher(nats.PublisherConfig{
+		URL:       cfg.Servers,
+		Marshaler: &nats.NATSMarshaler{},
+		JetStream: jetStream,
+	}, logger)
+	if err != nil {
+		return nil, errors.Join(err, sub.Close())
+	}
+
+	// the subscribers of the group share the output channel
+	return &Transport{
+		Subscriber:     sub,
+		Publisher:      pub,
+		Subscriptions:  1,
+		Workers:        concurrency,
+		retryTopic:     retryTopic(queue),
+		subscribeRetry: true,
+	}, nil
+}
+
+func (t *Transport) Close() error {
+	// GoChannel is both the subscriber and the publisher
+	if any(t.Subscriber) == any(t.Publisher) {
+		return t.Subscriber.Close()
+	}
+
+	return errors.Join(t.Subscriber.Close(), t.Publisher.Close())
+}
+
+// deadLetterQueue is DeadLetterQueue, or the queue of the node suffixed with ".dead-letters".
+func deadLetterQueue(cfg config.Queue) string {
+	if cfg.DeadLetterQueue != "" {
+		return cfg.DeadLetterQueue
+	}
+
+	return cfg.QueueName + ".dead-letters"
+}
+
+// retryTopic is the subject failed messages are published to, only the group of the node subscribes to it.
+func retryTopic(cfg config.Queue) string {
+	return cfg.QueueName + ".retry"
+}
+
+func watermillLogger(l *slog.Logger, module string) watermill.LoggerAdapter {
+	return watermill.NewSlogLogger(l.With(slog.String("module", module)))
+}
@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"context"
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestGoChannelTransport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	transport := NewGoChannelTransport(gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{}))
+	defer transport.Close()
+	assert.Equal(t, 1, transport.Subscriptions)
+	assert.Equal(t, 1, transport.Workers)
+
+	t.Run("responses are published to the reply topic", func(t *testing.T) {
+		replies, err := transport.Subscriber.Subscribe(ctx, "fsm_1")
+		require.NoError(t, err)
+
+		r := newPublisherResponder(transport.Publisher, "fs_to_fsm", time.Minute)
+		request := &Request{ID: uuid.New(), ReplyTo: "fsm_1"}
+		var reverted bool
+		require.NoError(t, r.respond(ctx, request, &Response{ID: request.ID}, func() { reverted = true }))
+
+		msg := receive(t, replies)
+		assert.Equal(t, request.ID.String(), msg.Metadata.Get(correlationIDKey))
+		assert.Equal(t, GobContentType, msg.Metadata.Get(ContentTypeKey))
+
+		assert.True(t, r.revert(request.ID))
+		assert.True(t, reverted)
+		assert.False(t, r.revert(request.ID))
+	})
+}
+
+func TestPublisherResponder_KeepReplacesRevert(t *testing.T) {
+	r := newPublisherResponder(nil, "fs_to_fsm", 200*time.Millisecond)
+	id := uuid.New()
+
+	var first, second bool
+	r.keep(id, func() { first = true })
+	time.Sleep(120 * time.Millisecond)
+	r.keep(id, func() { second = true })
+	time.Sleep(120 * time.Millisecond)
+
+	assert.True(t, r.revert(id), "timer of the replaced revert must not forget the newer one")
+	assert.False(t, first)
+	assert.True(t, second)
+
+	r.keep(id, func() {})
+	require.Eventually(t, func() bool {
+		r.mx.Lock()
+		defer r.mx.Unlock()
+		return len(r.reverts) == 0
+	}, time.Second, 10*time.Millisecond, "revert must be forgotten after the timeout")
+}
+
+func receive(t *testing.T, ch <-chan *message.Message) *message.Message {
+	t.Helper()
+
+	select {
+	case msg := <-ch:
+		msg.Ack()
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message has been received")
+		return nil
+	}
+}
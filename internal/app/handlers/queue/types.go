@@ -3,6 +3,7 @@ package queue
 import (
 	"errors"
 	"github.com/google/uuid"
+	"time"
 )
 
 type RequestType int
@@ -12,21 +13,85 @@ const (
 	UpdateType
 	OpenType
 	DeleteType
+	QuotaType   // sets QuotaBytes and QuotaFiles of OwnerID, zero stands for no limit
+	UsageType   // returns usage of OwnerID or of every owner if OwnerID is empty
+	MigrateType // moves FileID to the nodes of Replicas, the result is reported by a notification
+	RevertType  // reverts the request RevertID answered over AMQP, since the FSM has chosen another node
+	BatchType   // processes Items at once, they are answered by a single response
+	CopyType    // duplicates FileID into CopyID, the copy belongs to OwnerID (the owner of FileID if empty) and expires at ExpiresAt
+	StatType    // describes FileID without opening it
+	ListType    // lists the files of the node page by page, see Cursor and Limit
+	// TransferType moves a copy of FileID from Source to Target: Target pulls it, or Source pushes it if Push is set.
+	// Only the node doing the transfer answers, Size and Checksum of the response describe the transferred content.
+	TransferType
+	// IngestType downloads FileID from the http or https url Source, Size bytes at most, the download outlives the request
+	// and its result is reported by a notification. Target may name the node doing the ingest, any node takes it if empty.
+	IngestType
 )
 
+// Request is decoded by the codec of its content type, see api/queue/v1 for the schemas.
 type Request struct {
-	ID     uuid.UUID
-	Host   string
-	Type   RequestType
-	FileID uuid.UUID
-	Size   uint
+	ID         uuid.UUID   `json:"id"`
+	Host       string      `json:"host"`
+	Type       RequestType `json:"type"`
+	FileID     uuid.UUID   `json:"fileId"`
+	Size       uint        `json:"size"`
+	OwnerID    string      `json:"ownerId"`
+	QuotaBytes uint        `json:"quotaBytes"`
+	QuotaFiles uint        `json:"quotaFiles"`
+	ExpiresAt  time.Time   `json:"expiresAt"` // CreateType, CopyType and IngestType only, zero stands for never
+	Replicas   []string    `json:"replicas"`  // CreateType and UpdateType: peers the written file is replicated to, MigrateType: nodes taking the file over
+	ReplyTo    string      `json:"replyTo"`   // queue the response is published to in the AMQP response mode, the default one if empty
+	RevertID   uuid.UUID   `json:"revertId"`  // RevertType only
+	CopyID     uuid.UUID   `json:"copyId"`    // CopyType only
+	Cursor     string      `json:"cursor"`    // ListType only: Response.Cursor of the previous page, empty for the first one
+	Limit      uint        `json:"limit"`     // ListType only: files per page, 1000 if zero and 10000 at most
+	Source     string      `json:"source"`    // TransferType: host of the node the file is copied from, IngestType: url of the content
+	Target     string      `json:"target"`    // TransferType and IngestType: host of the node the file is copied to
+	Push       bool        `json:"push"`      // TransferType only: Source pushes the file instead of Target pulling it
+	// Items are the sub-requests of BatchType, they inherit Host if theirs is empty and can't be batches themselves
+	Items []Request `json:"items"`
+	// contentType the request has been encoded with, the response is encoded the same way
+	contentType string
 }
 
 type Response struct {
-	ID           uuid.UUID // must be equal to request.ID
-	Host         string
-	ConnectionID uuid.UUID
-	Err          string
+	ID           uuid.UUID    `json:"id"` // must be equal to request.ID
+	Host         string       `json:"host"`
+	ConnectionID uuid.UUID    `json:"connectionId"`
+	Err          string       `json:"err"`
+	Usage        []OwnerUsage `json:"usage"`
+	// Size and Checksum describe the copy of CopyType or TransferType, the checksum is the hex encoded SHA-256 of the content
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"`
+	// Items are the responses to the sub-requests of BatchType served by the node
+	Items []Response `json:"items"`
+	// Files describe the file of StatType, or the page of ListType where only FileID and Size are set
+	Files []FileInfo `json:"files"`
+	// Cursor of the next page of ListType, empty on the last one
+	Cursor string `json:"cursor"`
+}
+
+type FileInfo struct {
+	FileID       uuid.UUID `json:"fileId"`
+	Size         int64     `json:"size"`
+	Checksum     string    `json:"checksum"` // empty for files which haven't been written since checksums are kept
+	Version      uint64    `json:"version"`  // incremented every time the file is written
+	OwnerID      string    `json:"ownerId"`
+	AccessedAt   time.Time `json:"accessedAt"`
+	ModifiedAt   time.Time `json:"modifiedAt"`
+	ExpiresAt    time.Time `json:"expiresAt"` // zero stands for never
+	Replicas     []string  `json:"replicas"`  // peers which have acknowledged a copy of the current content
+	ErasureCoded bool      `json:"erasureCoded"`
+}
+
+type OwnerUsage struct {
+	OwnerID    string `json:"ownerId"`
+	Used       int64  `json:"used"`
+	Reserved   int64  `json:"reserved"`
+	Files      int64  `json:"files"`
+	QuotaBytes int64  `json:"quotaBytes"`
+	QuotaFiles int64  `json:"quotaFiles"`
 }
 
 func (r *Response) ToReturn() (string, string, error) {
@@ -37,3 +102,70 @@ func (r *Response) ToReturn() (string, string, error) {
 
 	return r.Host, r.ConnectionID.String(), err
 }
+
+type NotificationType int
+
+const (
+	UnavailableNotification NotificationType = iota
+	AvailableNotification
+	ExpiredNotification         // expired files have been deleted
+	ReplicatedNotification      // a written file has been pushed to the peers, see Replicas
+	MigratedNotification        // the file has been moved to the peers of Replicas and must be taken over by them
+	MigrationFailedNotification // the file has not been moved, see Err and Replicas
+	DrainingNotification        // the node accepts no new files, FileIDs are the files to be moved away
+	ActiveNotification          // the node has stopped draining
+	IngestedNotification        // the file has been downloaded, see Size and Checksum
+	IngestFailedNotification    // the file has not been downloaded and has been deleted, see Err
+)
+
+// Notification is sent to the FSM on the node's own initiative, e.g. when a disk goes down together with its files.
+type Notification struct {
+	ID      uuid.UUID        `json:"id"`
+	Host    string           `json:"host"`
+	Type    NotificationType `json:"type"`
+	FileIDs []uuid.UUID      `json:"fileIds"`
+	// Replicas is set for ReplicatedNotification and migration notifications only
+	Replicas []ReplicaStatus `json:"replicas"`
+	Err      string          `json:"err"`
+	// Size and Checksum are set for IngestedNotification only
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"`
+}
+
+type ReplicaStatus struct {
+	Host         string `json:"host"`
+	Acknowledged bool   `json:"acknowledged"`
+	Err          string `json:"err"`
+}
+
+type NodeState int
+
+const (
+	ActiveState NodeState = iota
+	ReadOnlyState
+	DrainingState
+)
+
+type HeartbeatType int
+
+const (
+	RegisterHeartbeat HeartbeatType = iota // sent on start
+	PeriodicHeartbeat
+	DeregisterHeartbeat // sent on graceful stop
+)
+
+// Heartbeat reports the capacity and the state of the node to the FSM.
+type Heartbeat struct {
+	ID                uuid.UUID     `json:"id"`
+	Host              string        `json:"host"`
+	Type              HeartbeatType `json:"type"`
+	Version           string        `json:"version"`
+	State             NodeState     `json:"state"`
+	MaxSize           int64         `json:"maxSize"`
+	CurrentSize       int64         `json:"currentSize"`
+	Reserved          int64         `json:"reserved"`
+	Free              int64         `json:"free"`
+	FileConnections   int           `json:"fileConnections"`
+	ReaderConnections int           `json:"readerConnections"`
+	SentAt            time.Time     `json:"sentAt"`
+}
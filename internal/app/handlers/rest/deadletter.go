@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/StratuStore/file-storage/internal/app/handlers/queue"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+const defaultDeadLettersLimit = 100
+
+// DeadLetters inspects and replays the queue messages the node has given up on.
+type DeadLetters interface {
+	DeadLetters(ctx context.Context, limit int) ([]queue.DeadLetter, error)
+	ReplayDeadLetters(ctx context.Context, limit int) (int, error)
+}
+
+type ReplayResponse struct {
+	Replayed int `json:"replayed"`
+}
+
+// ListDeadLetters is a GET request of the operator, limit query parameter bounds the number of messages
+func (h *Handler) ListDeadLetters(w http.ResponseWriter, req *http.Request) {
+	l := h.l.With(slog.String("op", "internal.app.handlers.rest.ListDeadLetters"))
+
+	limit, err := h.deadLettersLimit(w, req)
+	if err != nil {
+		return
+	}
+
+	deadLetters, err := h.DeadLetters.DeadLetters(req.Context(), limit)
+	if errors.Is(err, queue.ErrDeadLettersUnsupported) {
+		_ = h.handleError(w, http.StatusNotImplemented, err, err.Error())
+		return
+	}
+	if err != nil {
+		l.Error("unable to list dead letters", slog.String("err", err.Error()))
+		_ = h.handleError(w, http.StatusBadGateway, err, "unable to list dead letters")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(deadLetters); err != nil {
+		l.Error("unable to encode response", slog.String("err", err.Error()))
+	}
+}
+
+// ReplayDeadLetters is a POST request of the operator moving dead letters back to the queue of the node
+func (h *Handler) ReplayDeadLetters(w http.ResponseWriter, req *http.Request) {
+	l := h.l.With(slog.String("op", "internal.app.handlers.rest.ReplayDeadLetters"))
+
+	limit, err := h.deadLettersLimit(w, req)
+	if err != nil {
+		return
+	}
+
+	replayed, err := h.DeadLetters.ReplayDeadLetters(req.Context(), limit)
+	if errors.Is(err, queue.ErrDeadLettersUnsupported) {
+		_ = h.handleError(w, http.StatusNotImplemented, err, err.Error())
+		return
+	}
+	if err != nil {
+		l.Error("unable to replay dead letters", slog.String("err", err.Error()), slog.Int("replayed", replayed))
+		_ = h.handleError(w, http.StatusBadGateway, err, "unable to replay dead letters, replayed "+strconv.Itoa(replayed))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(ReplayResponse{Replayed: replayed}); err != nil {
+		l.Error("unable to encode response", slog.String("err", err.Error()))
+	}
+}
+
+func (h *Handler) deadLettersLimit(w http.ResponseWriter, req *http.Request) (int, error) {
+	if h.DeadLetters == nil {
+		err := errors.New("dead letters are not available")
+		_ = h.handleError(w, http.StatusServiceUnavailable, err, err.Error())
+		return 0, err
+	}
+
+	rawLimit := req.URL.Query().Get("limit")
+	if rawLimit == "" {
+		return defaultDeadLettersLimit, nil
+	}
+	limit, err := strconv.Atoi(rawLimit)
+	if err != nil || limit <= 0 {
+		err = errors.New("invalid limit")
+		_ = h.handleError(w, http.StatusBadRequest, err, err.Error())
+		return 0, err
+	}
+
+	return limit, nil
+}
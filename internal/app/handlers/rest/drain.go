@@ -0,0 +1,43 @@
+package rest
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+type DrainResponse struct {
+	Draining bool `json:"draining"`
+	Files    int  `json:"files"`
+}
+
+// StartDrain is a POST request of the operator decommissioning the node
+func (h *Handler) StartDrain(w http.ResponseWriter, req *http.Request) {
+	l := h.l.With(slog.String("op", "internal.app.handlers.rest.StartDrain"))
+
+	files, err := h.useCases.StartDrain(req.Context())
+	if err != nil {
+		l.Error("unable to notify fsm about draining", slog.String("err", err.Error()))
+		_ = h.handleError(w, http.StatusBadGateway, err, "node is draining, but fsm hasn't been notified")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(DrainResponse{Draining: true, Files: files}); err != nil {
+		l.Error("unable to encode response", slog.String("err", err.Error()))
+	}
+}
+
+// StopDrain is a DELETE request of the operator
+func (h *Handler) StopDrain(w http.ResponseWriter, req *http.Request) {
+	l := h.l.With(slog.String("op", "internal.app.handlers.rest.StopDrain"))
+
+	if err := h.useCases.StopDrain(req.Context()); err != nil {
+		l.Error("unable to notify fsm about activation", slog.String("err", err.Error()))
+		_ = h.handleError(w, http.StatusBadGateway, err, "node is active, but fsm hasn't been notified")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(DrainResponse{}); err != nil {
+		l.Error("unable to encode response", slog.String("err", err.Error()))
+	}
+}
@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"github.com/StratuStore/file-storage/internal/app/replication"
+	"github.com/StratuStore/file-storage/internal/app/usecases"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+)
+
+// migrationNotifier records migrations and draining, the other notifications aren't sent by them.
+type migrationNotifier struct {
+	usecases.Notifier
+	migrated []uuid.UUID
+	draining bool
+	mx       sync.Mutex
+}
+
+func (n *migrationNotifier) NotifyReplicated(context.Context, uuid.UUID, []replication.Status) error {
+	return nil
+}
+
+func (n *migrationNotifier) NotifyMigrated(_ context.Context, fileID uuid.UUID, _ []replication.Status, migrateErr error) error {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	if migrateErr == nil {
+		n.migrated = append(n.migrated, fileID)
+	}
+
+	return nil
+}
+
+func (n *migrationNotifier) NotifyDraining(_ context.Context, draining bool, _ []uuid.UUID) error {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	n.draining = draining
+
+	return nil
+}
+
+func TestDrain_MigratesFilesToTargets(t *testing.T) {
+	source, target := newTestNode(t), newTestNode(t)
+	notifier := &migrationNotifier{}
+	source.useCases.Notifier = notifier
+	content := bytes.Repeat([]byte("drained content "), 100)
+
+	id := uuid.New()
+	source.write(t, id, content, nil)
+
+	request, err := http.NewRequest(http.MethodPost, source.url+"/internal/drain", nil)
+	require.NoError(t, err)
+	request.Header.Set("Authorization", "Bearer "+testToken)
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	assert.True(t, source.controller.Draining())
+	assert.True(t, notifier.draining)
+
+	file, err := source.controller.File(id)
+	require.NoError(t, err)
+	blob := file.FullPath()
+
+	require.NoError(t, source.useCases.MigrateFile(context.Background(), id, []string{target.url}))
+	assert.Equal(t, []uuid.UUID{id}, notifier.migrated)
+
+	_, err = source.controller.File(id)
+	assert.ErrorIs(t, err, os.ErrNotExist, "migrated file must be deleted")
+	_, err = os.Stat(blob)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	data, err := target.read(id)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+	moved, err := target.controller.File(id)
+	require.NoError(t, err)
+	assert.Empty(t, moved.Metadata().Replicas, "the only target has no replicas")
+}
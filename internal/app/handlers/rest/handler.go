@@ -2,8 +2,11 @@ package rest
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
+	"expvar"
+	"github.com/StratuStore/file-storage/internal/app/signing"
 	"github.com/StratuStore/file-storage/internal/app/usecases"
 	"github.com/StratuStore/file-storage/internal/libs/config"
 	"github.com/go-chi/chi/v5"
@@ -11,6 +14,7 @@ import (
 	"github.com/go-chi/cors"
 	"log/slog"
 	"net/http"
+	"net/netip"
 	"strings"
 )
 
@@ -20,6 +24,12 @@ type Handler struct {
 	r        chi.Router
 	cfg      *config.Config
 	server   *http.Server
+	// DeadLetters is set once the queue handler is created
+	DeadLetters DeadLetters
+	// Signer verifies signed urls, they are rejected if it isn't set
+	Signer *signing.Signer
+	// TrustedProxies are the networks of the proxies whose forwarded client address is trusted
+	TrustedProxies []netip.Prefix
 }
 
 func NewHandler(useCases *usecases.UseCases, logger *slog.Logger, cfg *config.Config) *Handler {
@@ -35,6 +45,8 @@ func (h *Handler) Register() {
 	r := h.r
 
 	r.Use(middleware.RequestID)
+	// the peer is kept before RealIP replaces it by the client-supplied headers
+	r.Use(keepPeerAddr)
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.URLFormat)
@@ -58,9 +70,35 @@ func (h *Handler) Register() {
 
 	r.Route("/files", func(r chi.Router) {
 		r.Get("/read", h.ReadFile)
+		r.Get("/signed/{fileID}", h.ReadSignedFile)
 		r.Post("/write", h.WriteFile)
 		r.Post("/close", h.CloseFile)
 	})
+
+	// internal routes are called by other storage nodes and operators only
+	r.Route("/internal", func(r chi.Router) {
+		r.Use(h.authenticateNode)
+		r.Put("/replicas/{fileID}", h.StoreReplica)
+		r.Get("/replicas/{fileID}", h.FetchReplica)
+		r.Handle("/metrics", expvar.Handler())
+		r.Post("/drain", h.StartDrain)
+		r.Delete("/drain", h.StopDrain)
+		r.Get("/dead-letters", h.ListDeadLetters)
+		r.Post("/dead-letters/replay", h.ReplayDeadLetters)
+	})
+}
+
+// authenticateNode accepts requests bearing the exchange token shared by the nodes and the FSM.
+func (h *Handler) authenticateNode(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !ok || h.cfg.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.cfg.Token)) != 1 {
+			_ = h.handleError(w, http.StatusUnauthorized, nil, "invalid token")
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
 }
 
 func (h *Handler) Start(ctx context.Context) error {
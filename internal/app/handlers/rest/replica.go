@@ -0,0 +1,91 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/StratuStore/file-storage/internal/app/fileio"
+	"github.com/StratuStore/file-storage/internal/app/replication"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// StoreReplica is a PUT request of another storage node
+// Body must be a file, its size, checksum and metadata are passed in headers
+func (h *Handler) StoreReplica(w http.ResponseWriter, req *http.Request) {
+	l := h.l.With(slog.String("op", "internal.app.handlers.rest.StoreReplica"))
+
+	fileID, err := uuid.Parse(chi.URLParam(req, "fileID"))
+	if err != nil {
+		l.Debug("unable to decode fileID", slog.String("err", err.Error()))
+		_ = h.handleError(w, http.StatusBadRequest, err, "invalid fileID")
+		return
+	}
+
+	size, err := strconv.ParseInt(req.Header.Get(replication.SizeHeader), 10, 64)
+	if err != nil {
+		l.Debug("unable to decode size", slog.String("err", err.Error()))
+		_ = h.handleError(w, http.StatusBadRequest, err, "invalid size")
+		return
+	}
+
+	var metadata fileio.Metadata
+	if raw := req.Header.Get(replication.MetadataHeader); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &metadata); err != nil {
+			l.Debug("unable to decode metadata", slog.String("err", err.Error()))
+			_ = h.handleError(w, http.StatusBadRequest, err, "invalid metadata")
+			return
+		}
+	}
+
+	checksum := req.Header.Get(replication.ChecksumHeader)
+	if checksum == "" {
+		_ = h.handleError(w, http.StatusBadRequest, nil, "checksum is required")
+		return
+	}
+
+	err = h.useCases.StoreReplica(req.Context(), fileID, req.Body, size, checksum, metadata)
+	if errors.Is(err, fileio.ErrChecksumMismatch) {
+		l.Warn("replica is corrupted", slog.String("id", fileID.String()))
+		_ = h.handleError(w, http.StatusUnprocessableEntity, err, "checksum mismatch")
+		return
+	}
+	if err != nil {
+		l.Error("unable to store replica", slog.String("err", err.Error()))
+		_ = h.handleError(w, http.StatusInsufficientStorage, err, "unable to store replica")
+		return
+	}
+}
+
+// FetchReplica is a GET request of another storage node repairing its copy of the file or pulling it
+func (h *Handler) FetchReplica(w http.ResponseWriter, req *http.Request) {
+	l := h.l.With(slog.String("op", "internal.app.handlers.rest.FetchReplica"))
+
+	fileID, err := uuid.Parse(chi.URLParam(req, "fileID"))
+	if err != nil {
+		l.Debug("unable to decode fileID", slog.String("err", err.Error()))
+		_ = h.handleError(w, http.StatusBadRequest, err, "invalid fileID")
+		return
+	}
+
+	reader, size, metadata, err := h.useCases.ReplicaReader(req.Context(), fileID)
+	if err != nil {
+		l.Debug("unable to open replica", slog.String("err", err.Error()))
+		_ = h.handleError(w, http.StatusNotFound, err, "replica is unavailable")
+		return
+	}
+	defer reader.Close()
+
+	w.Header().Set(replication.SizeHeader, strconv.FormatInt(size, 10))
+	w.Header().Set(replication.ChecksumHeader, metadata.Checksum)
+	if rawMetadata, err := json.Marshal(metadata); err == nil {
+		w.Header().Set(replication.MetadataHeader, string(rawMetadata))
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	if _, err := io.Copy(w, reader); err != nil {
+		l.Error("unable to send replica", slog.String("err", err.Error()))
+	}
+}
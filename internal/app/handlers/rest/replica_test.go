@@ -0,0 +1,260 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"github.com/StratuStore/file-storage/internal/app/connector"
+	"github.com/StratuStore/file-storage/internal/app/controller"
+	"github.com/StratuStore/file-storage/internal/app/fileio"
+	"github.com/StratuStore/file-storage/internal/app/fsm"
+	"github.com/StratuStore/file-storage/internal/app/replication"
+	"github.com/StratuStore/file-storage/internal/app/usecases"
+	"github.com/StratuStore/file-storage/internal/libs/config"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+const testToken = "token"
+
+type testNode struct {
+	url        string
+	useCases   *usecases.UseCases
+	controller *controller.Controller
+	handler    *Handler
+}
+
+// newTestNode starts a storage node serving its internal endpoints in-process.
+func newTestNode(t *testing.T) *testNode {
+	layout, err := controller.NewLayout(2, 2)
+	require.NoError(t, err)
+	placement, err := controller.NewPlacement(controller.FreeSpacePlacement)
+	require.NoError(t, err)
+	ctrl, err := controller.NewController([]*controller.Disk{controller.NewDisk(t.TempDir(), 1<<20)}, layout, placement, controller.Watermarks{Low: 1, High: 1})
+	require.NoError(t, err)
+
+	var h *Handler
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		h.r.ServeHTTP(w, req)
+	}))
+	t.Cleanup(server.Close)
+
+	l := slog.New(slog.DiscardHandler)
+	cfg := &config.Config{RabbitMQ: config.RabbitMQ{Token: testToken}, Env: "dev"}
+	uc := usecases.NewUseCases(
+		connector.NewConnector[*usecases.FileWithHost](),
+		connector.NewConnector[usecases.Reader](),
+		ctrl,
+		replication.New(server.URL, testToken, time.Minute, 512),
+		fsm.New(fsm.Options{Token: testToken, Timeout: time.Minute}),
+		l, 512, 4096, false,
+	)
+	h = NewHandler(uc, l, cfg)
+	h.Register()
+
+	return &testNode{url: server.URL, useCases: uc, controller: ctrl, handler: h}
+}
+
+func (n *testNode) write(t *testing.T, id uuid.UUID, content []byte, peers []string) {
+	ctx := context.Background()
+	connectionID, err := n.useCases.CreateFile(ctx, "", id, int64(len(content)), "", time.Time{}, peers)
+	require.NoError(t, err)
+	require.NoError(t, n.useCases.Write(ctx, connectionID, bytes.NewReader(content), int64(len(content))))
+}
+
+func (n *testNode) read(id uuid.UUID) ([]byte, error) {
+	ctx := context.Background()
+	connectionID, err := n.useCases.OpenFile(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := n.useCases.Read(ctx, connectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(reader)
+}
+
+func TestReplica_RepairFromPeer(t *testing.T) {
+	primary, replica := newTestNode(t), newTestNode(t)
+	content := bytes.Repeat([]byte("replicated content "), 100)
+
+	id := uuid.New()
+	primary.write(t, id, content, []string{replica.url})
+
+	file, err := primary.controller.File(id)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return len(file.Metadata().Replicas) == 1
+	}, 5*time.Second, 10*time.Millisecond, "replica must be acknowledged")
+
+	data, err := replica.read(id)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+
+	t.Run("missing blob", func(t *testing.T) {
+		require.NoError(t, os.Remove(file.FullPath()))
+
+		data, err := primary.read(id)
+		require.NoError(t, err)
+		assert.Equal(t, content, data)
+	})
+
+	t.Run("corrupted blob", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file.FullPath(), bytes.Repeat([]byte("x"), len(content)), 0o666))
+
+		ctx := context.Background()
+		connectionID, err := primary.useCases.OpenFile(ctx, id)
+		require.NoError(t, err)
+		reader, err := primary.useCases.Read(ctx, connectionID)
+		require.NoError(t, err)
+		_, err = reader.Seek(10, io.SeekStart)
+		require.NoError(t, err)
+		_, err = io.ReadFull(reader, make([]byte, 10))
+		require.NoError(t, err, "range reads aren't verified against the checksum of the whole file")
+		require.NoError(t, reader.Close())
+
+		_, err = primary.read(id)
+		assert.ErrorIs(t, err, fileio.ErrChecksumMismatch, "corrupted content must be reported once it has been read")
+
+		data, err := primary.read(id)
+		require.NoError(t, err)
+		assert.Equal(t, content, data)
+
+		data, err = os.ReadFile(file.FullPath())
+		require.NoError(t, err)
+		assert.Equal(t, content, data, "blob must be repaired")
+	})
+
+	t.Run("damaged replica keeps the local blob", func(t *testing.T) {
+		replicaFile, err := replica.controller.File(id)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(replicaFile.FullPath(), bytes.Repeat([]byte("y"), len(content)), 0o666))
+		corrupted := bytes.Repeat([]byte("x"), len(content))
+		require.NoError(t, os.WriteFile(file.FullPath(), corrupted, 0o666))
+		size := primary.controller.CurrentSize.Load()
+
+		_, err = primary.read(id)
+		require.ErrorIs(t, err, fileio.ErrChecksumMismatch)
+
+		data, err := os.ReadFile(file.FullPath())
+		require.NoError(t, err)
+		assert.Equal(t, corrupted, data, "failed repair must leave the blob untouched")
+		assert.Equal(t, size, primary.controller.CurrentSize.Load())
+		assert.Equal(t, []string{replica.url}, file.Metadata().Replicas)
+
+		require.NoError(t, os.WriteFile(replicaFile.FullPath(), content, 0o666))
+		require.NoError(t, os.WriteFile(file.FullPath(), content, 0o666))
+	})
+
+	t.Run("failed replica keeps the existing copy", func(t *testing.T) {
+		replicaFile, err := replica.controller.File(id)
+		require.NoError(t, err)
+		metadata := replicaFile.Metadata()
+		ctx := context.Background()
+
+		err = replica.useCases.StoreReplica(ctx, id, bytes.NewReader(bytes.Repeat([]byte("z"), len(content))), int64(len(content)), metadata.Checksum, metadata)
+		assert.ErrorIs(t, err, fileio.ErrChecksumMismatch)
+		err = replica.useCases.StoreReplica(ctx, id, bytes.NewReader(content[:10]), int64(len(content)), metadata.Checksum, metadata)
+		assert.Error(t, err, "truncated replica")
+
+		data, err := replica.read(id)
+		require.NoError(t, err, "existing copy must not be deleted")
+		assert.Equal(t, content, data)
+	})
+
+	t.Run("replica repairs from primary", func(t *testing.T) {
+		replicaFile, err := replica.controller.File(id)
+		require.NoError(t, err)
+		assert.Equal(t, []string{primary.url}, replicaFile.Metadata().Replicas)
+		require.NoError(t, os.Remove(replicaFile.FullPath()))
+
+		data, err := replica.read(id)
+		require.NoError(t, err)
+		assert.Equal(t, content, data)
+	})
+	t.Run("stored replica keeps the local metadata", func(t *testing.T) {
+		replicaFile, err := replica.controller.File(id)
+		require.NoError(t, err)
+		before := replicaFile.Metadata()
+		sent := fileio.Metadata{
+			Checksum:  before.Checksum,
+			OwnerID:   "other",
+			ExpiresAt: time.Now().Add(time.Hour),
+			Replicas:  []string{"http://fs-9:5000"},
+		}
+
+		require.NoError(t, replica.useCases.StoreReplica(context.Background(), id, bytes.NewReader(content), int64(len(content)), sent.Checksum, sent))
+		after := replicaFile.Metadata()
+		assert.Equal(t, before.OwnerID, after.OwnerID)
+		assert.Equal(t, before.ExpiresAt, after.ExpiresAt)
+		assert.Equal(t, before.Checksum, after.Checksum)
+		assert.Equal(t, sent.Replicas, after.Replicas)
+	})
+}
+
+func TestReplica_Transfer(t *testing.T) {
+	source, target := newTestNode(t), newTestNode(t)
+	content := bytes.Repeat([]byte("transferred content "), 100)
+	ctx := context.Background()
+
+	t.Run("pull", func(t *testing.T) {
+		id := uuid.New()
+		source.write(t, id, content, nil)
+
+		size, checksum, err := target.useCases.PullFile(ctx, id, source.url)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(content)), size)
+
+		file, err := target.controller.File(id)
+		require.NoError(t, err)
+		assert.Equal(t, checksum, file.Metadata().Checksum)
+		assert.Equal(t, []string{source.url}, file.Metadata().Replicas)
+
+		data, err := target.read(id)
+		require.NoError(t, err)
+		assert.Equal(t, content, data)
+
+		_, _, err = target.useCases.PullFile(ctx, id, source.url)
+		assert.Error(t, err, "existing file must not be overwritten")
+	})
+
+	t.Run("push", func(t *testing.T) {
+		id := uuid.New()
+		source.write(t, id, content, nil)
+
+		size, checksum, err := source.useCases.PushFile(ctx, id, target.url)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(content)), size)
+
+		file, err := target.controller.File(id)
+		require.NoError(t, err)
+		assert.Equal(t, checksum, file.Metadata().Checksum)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		id := uuid.New()
+		_, _, err := target.useCases.PullFile(ctx, id, source.url)
+		require.Error(t, err)
+
+		_, err = target.controller.File(id)
+		assert.ErrorIs(t, err, os.ErrNotExist, "nothing must be left behind")
+	})
+}
+
+func TestReplica_RejectsUnauthenticatedNodes(t *testing.T) {
+	node := newTestNode(t)
+
+	response, err := http.Get(node.url + replication.ReplicasPath + uuid.NewString())
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
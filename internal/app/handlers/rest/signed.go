@@ -0,0 +1,158 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"github.com/StratuStore/file-storage/internal/app/signing"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"time"
+)
+
+// ReadSignedFile is a GET request of a url signed by the FSM, the file is read without a connection opened beforehand
+func (h *Handler) ReadSignedFile(w http.ResponseWriter, req *http.Request) {
+	l := h.l.With(slog.String("op", "internal.app.handlers.rest.ReadSignedFile"))
+
+	if h.Signer == nil {
+		_ = h.handleError(w, http.StatusNotFound, nil, "signed urls are disabled")
+		return
+	}
+
+	fileID, err := uuid.Parse(chi.URLParam(req, "fileID"))
+	if err != nil {
+		_ = h.handleError(w, http.StatusBadRequest, err, "invalid fileID")
+		return
+	}
+
+	claims, err := h.Signer.Verify(fileID, signing.ReadOperation, req.URL.Query(), h.clientAddr(req), time.Now())
+	if err != nil {
+		l.Debug("rejecting signed url", slog.String("id", fileID.String()), slog.String("err", err.Error()))
+		_ = h.handleError(w, http.StatusForbidden, err, err.Error())
+		return
+	}
+
+	reader, err := h.useCases.ReadFile(req.Context(), fileID)
+	if err != nil {
+		l.Debug("unable to open file", slog.String("err", err.Error()))
+		_ = h.handleError(w, http.StatusNotFound, err, "file error")
+		return
+	}
+	defer reader.Close()
+
+	var content io.ReadSeeker = reader
+	if claims.Range != nil {
+		if content, err = newSectionReader(reader, claims.Range.First, claims.Range.Last-claims.Range.First+1); err != nil {
+			l.Debug("unable to read range of file", slog.String("err", err.Error()))
+			_ = h.handleError(w, http.StatusRequestedRangeNotSatisfiable, err, "range of signed url is outside of the file")
+			return
+		}
+	}
+
+	filename := req.URL.Query().Get("name")
+	if filename != "" {
+		w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	}
+
+	http.ServeContent(w, req, filename, time.Time{}, content)
+}
+
+type peerAddrKey struct{}
+
+// keepPeerAddr keeps the address of the peer of the connection, which can't be forged by the client.
+func keepPeerAddr(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), peerAddrKey{}, req.RemoteAddr)))
+	})
+}
+
+// clientAddr is the address of the peer, or the forwarded one if the peer is a trusted proxy.
+func (h *Handler) clientAddr(req *http.Request) netip.Addr {
+	peer, ok := req.Context().Value(peerAddrKey{}).(string)
+	if !ok {
+		peer = req.RemoteAddr
+	}
+
+	addr := parseAddr(peer)
+	for _, proxy := range h.TrustedProxies {
+		if proxy.Contains(addr.Unmap()) {
+			return parseAddr(req.RemoteAddr)
+		}
+	}
+
+	return addr
+}
+
+// parseAddr parses the address with or without a port, the address is invalid if it can't be parsed.
+func parseAddr(raw string) netip.Addr {
+	host := raw
+	if h, _, err := net.SplitHostPort(raw); err == nil {
+		host = h
+	}
+	addr, _ := netip.ParseAddr(host)
+
+	return addr
+}
+
+var errOutsideOfFile = errors.New("section starts after the end of the file")
+
+// sectionReader limits the reader to size bytes from offset, so the client can't seek out of the signed range.
+type sectionReader struct {
+	r      io.ReadSeeker
+	offset int64
+	size   int64
+	pos    int64
+}
+
+// newSectionReader shortens the section if the file ends before it does.
+func newSectionReader(r io.ReadSeeker, offset, size int64) (*sectionReader, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if offset >= end {
+		return nil, errOutsideOfFile
+	}
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	return &sectionReader{r: r, offset: offset, size: min(size, end-offset)}, nil
+}
+
+func (s *sectionReader) Read(p []byte) (n int, err error) {
+	if s.pos >= s.size {
+		return 0, io.EOF
+	}
+	if remaining := s.size - s.pos; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	n, err = s.r.Read(p)
+	s.pos += int64(n)
+
+	return n, err
+}
+
+func (s *sectionReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += s.pos
+	case io.SeekEnd:
+		offset += s.size
+	}
+	if offset < 0 {
+		return 0, errors.New("seek before the start of the section")
+	}
+
+	if _, err := s.r.Seek(s.offset+min(offset, s.size), io.SeekStart); err != nil {
+		return 0, err
+	}
+	s.pos = offset
+
+	return offset, nil
+}
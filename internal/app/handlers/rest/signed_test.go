@@ -0,0 +1,99 @@
+package rest
+
+import (
+	"bytes"
+	"github.com/StratuStore/file-storage/internal/app/signing"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestReadSignedFile(t *testing.T) {
+	node := newTestNode(t)
+	signer, err := signing.NewSigner(map[string]string{"key": "secret"}, "key")
+	require.NoError(t, err)
+	node.handler.Signer = signer
+
+	content := bytes.Repeat([]byte("signed content "), 100)
+	id := uuid.New()
+	node.write(t, id, content, nil)
+
+	get := func(t *testing.T, fileID uuid.UUID, claims signing.Claims, header http.Header) (int, []byte) {
+		claims.FileID = fileID
+		request, err := http.NewRequest(http.MethodGet, node.url+"/files/signed/"+fileID.String()+"?"+signer.Sign(claims).Encode(), nil)
+		require.NoError(t, err)
+		for k, v := range header {
+			request.Header[k] = v
+		}
+		response, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		defer response.Body.Close()
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+
+		return response.StatusCode, body
+	}
+	valid := signing.Claims{Operation: signing.ReadOperation, ExpiresAt: time.Now().Add(time.Minute)}
+
+	status, body := get(t, id, valid, nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, content, body)
+
+	t.Run("range", func(t *testing.T) {
+		claims := valid
+		claims.Range = &signing.Range{First: 15, Last: 29}
+		status, body := get(t, id, claims, nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, content[15:30], body)
+
+		status, body = get(t, id, claims, http.Header{"Range": {"bytes=5-"}})
+		require.Equal(t, http.StatusPartialContent, status)
+		assert.Equal(t, content[20:30], body, "client ranges apply within the signed one")
+
+		claims.Range = &signing.Range{First: int64(len(content)), Last: int64(len(content)) + 10}
+		status, _ = get(t, id, claims, nil)
+		assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, status)
+	})
+
+	t.Run("bound to client", func(t *testing.T) {
+		claims := valid
+		claims.IP = netip.MustParseAddr("203.0.113.7")
+		status, _ := get(t, id, claims, nil)
+		assert.Equal(t, http.StatusForbidden, status)
+
+		status, _ = get(t, id, claims, http.Header{"X-Real-Ip": {"203.0.113.7"}})
+		assert.Equal(t, http.StatusForbidden, status, "forwarded address of an untrusted peer must be ignored")
+
+		claims.IP = netip.MustParseAddr("127.0.0.1")
+		status, body := get(t, id, claims, http.Header{"X-Real-Ip": {"203.0.113.7"}})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, content, body)
+
+		node.handler.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+		defer func() { node.handler.TrustedProxies = nil }()
+		claims.IP = netip.MustParseAddr("203.0.113.7")
+		status, body = get(t, id, claims, http.Header{"X-Real-Ip": {"203.0.113.7"}})
+		require.Equal(t, http.StatusOK, status, "trusted proxy forwards the address of the client")
+		assert.Equal(t, content, body)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		claims := valid
+		claims.ExpiresAt = time.Now().Add(-time.Second)
+		status, _ := get(t, id, claims, nil)
+		assert.Equal(t, http.StatusForbidden, status, "expired")
+
+		response, err := http.Get(node.url + "/files/signed/" + id.String() + "?" + signer.Sign(valid).Encode())
+		require.NoError(t, err)
+		response.Body.Close()
+		assert.Equal(t, http.StatusForbidden, response.StatusCode, "signed for another file")
+
+		status, _ = get(t, uuid.New(), valid, nil)
+		assert.Equal(t, http.StatusNotFound, status, "missing file")
+	})
+}
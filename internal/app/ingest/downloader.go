@@ -0,0 +1,166 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrUnsupportedSource = errors.New("source must be an absolute http or https url")
+	ErrForbiddenAddress  = errors.New("source resolves to a non-public address")
+	ErrTooManyRedirects  = errors.New("source redirects too many times")
+)
+
+// reserved ranges which aren't reachable over the internet, on top of loopback, private, link-local and multicast ones
+var reserved = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // this network
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved and broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, embeds IPv4 addresses
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local-use NAT64
+	netip.MustParsePrefix("100::/64"),        // discard
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("2002::/16"),       // 6to4, embeds IPv4 addresses
+}
+
+type Options struct {
+	// Timeout bounds the whole download, reading of the content included.
+	Timeout time.Duration
+	// MaxRedirects is the number of redirects followed before the download fails.
+	MaxRedirects int
+	// AllowPrivate lets sources resolve to non-public addresses, which is meant for development only.
+	AllowPrivate bool
+}
+
+// Downloader fetches content from outside of the cluster, keeping it from reaching the internal network.
+type Downloader struct {
+	client  *http.Client
+	options Options
+}
+
+func New(options Options) *Downloader {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !options.AllowPrivate {
+		dialer.Control = control
+	}
+
+	d := &Downloader{options: options}
+	d.client = &http.Client{
+		Transport: &http.Transport{
+			// a proxy would connect to the source on our behalf, bypassing the check of the address
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: time.Minute,
+			IdleConnTimeout:       90 * time.Second,
+			MaxIdleConns:          16,
+		},
+		CheckRedirect: d.checkRedirect,
+		Timeout:       options.Timeout,
+	}
+
+	return d
+}
+
+// Validate rejects sources which can't be downloaded without connecting to them.
+func (d *Downloader) Validate(link string) error {
+	_, err := d.parse(link)
+
+	return err
+}
+
+// Download requests the source, the size is -1 if the source doesn't tell it. The body must be closed by the caller.
+func (d *Downloader) Download(ctx context.Context, link string) (body io.ReadCloser, size int64, err error) {
+	source, err := d.parse(link)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source.String(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response, err := d.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		response.Body.Close()
+		return nil, 0, fmt.Errorf("source responded with %s", response.Status)
+	}
+
+	return response.Body, response.ContentLength, nil
+}
+
+func (d *Downloader) parse(link string) (*url.URL, error) {
+	source, err := url.Parse(link)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupportedSource, err)
+	}
+	if (source.Scheme != "http" && source.Scheme != "https") || source.Hostname() == "" {
+		return nil, ErrUnsupportedSource
+	}
+
+	// literal addresses are rejected at once, host names only once they are resolved
+	if addr, err := netip.ParseAddr(source.Hostname()); err == nil && !d.options.AllowPrivate && !Public(addr) {
+		return nil, fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+	}
+
+	return source, nil
+}
+
+func (d *Downloader) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > d.options.MaxRedirects {
+		return ErrTooManyRedirects
+	}
+	_, err := d.parse(req.URL.String())
+
+	return err
+}
+
+// control is called for every address the host resolves to, right before connecting to it.
+func control(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !Public(addr) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+	}
+
+	return nil
+}
+
+// Public reports whether the address is reachable over the internet.
+func Public(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range reserved {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
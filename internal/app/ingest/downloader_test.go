@@ -0,0 +1,90 @@
+package ingest
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestPublic(t *testing.T) {
+	for addr, public := range map[string]bool{
+		"8.8.8.8":          true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"255.255.255.255":  false,
+		"::1":              false,
+		"fd00::1":          false,
+		"fe80::1":          false,
+		"::ffff:127.0.0.1": false,
+		"64:ff9b::a00:1":   false,
+	} {
+		assert.Equal(t, public, Public(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestDownloader_RejectsInternalSources(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("internal"))
+	}))
+	defer server.Close()
+
+	d := New(Options{Timeout: time.Second, MaxRedirects: 5})
+	for _, link := range []string{"ftp://example.com/file", "file:///etc/passwd", "/relative", "http://10.0.0.1/file", server.URL} {
+		assert.Error(t, d.Validate(link), link)
+	}
+
+	t.Run("host names are checked once resolved", func(t *testing.T) {
+		_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+		require.NoError(t, err)
+		link := "http://localhost:" + port
+		require.NoError(t, d.Validate(link))
+
+		_, _, err = d.Download(context.Background(), link)
+		assert.ErrorIs(t, err, ErrForbiddenAddress)
+	})
+}
+
+func TestDownloader_FollowsLimitedRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/content", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("content"))
+	})
+	mux.HandleFunc("/redirect/{n}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("n") == "0" {
+			http.Redirect(w, r, "/content", http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, "/redirect/0", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	d := New(Options{Timeout: time.Second, MaxRedirects: 1, AllowPrivate: true})
+
+	body, size, err := d.Download(context.Background(), server.URL+"/redirect/0")
+	require.NoError(t, err)
+	content, err := io.ReadAll(body)
+	require.NoError(t, body.Close())
+	require.NoError(t, err)
+	assert.Equal(t, "content", string(content))
+	assert.EqualValues(t, len(content), size)
+
+	_, _, err = d.Download(context.Background(), server.URL+"/redirect/1")
+	assert.ErrorIs(t, err, ErrTooManyRedirects)
+
+	_, _, err = d.Download(context.Background(), server.URL+"/missing")
+	assert.Error(t, err)
+}
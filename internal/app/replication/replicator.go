@@ -0,0 +1,184 @@
+package replication
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/StratuStore/file-storage/internal/app/fileio"
+	"github.com/go-resty/resty/v2"
+	"github.com/google/uuid"
+	"io"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ReplicasPath is the internal endpoint of every node which accepts replicas.
+const ReplicasPath = "/internal/replicas/"
+
+const (
+	ChecksumHeader = "X-Checksum-Sha256"
+	SizeHeader     = "X-File-Size"
+	MetadataHeader = "X-File-Metadata"
+)
+
+type Status struct {
+	Host         string
+	Acknowledged bool
+	Err          string
+}
+
+// Replicator streams files to peer nodes designated by the FSM.
+type Replicator struct {
+	client     *resty.Client
+	host       string
+	token      string
+	bufferSize int
+}
+
+// New creates a replicator of the node reachable at host, replicas record the host to be repaired from.
+func New(host, token string, timeout time.Duration, bufferSize int) *Replicator {
+	return &Replicator{
+		client:     resty.New().SetTimeout(timeout),
+		host:       host,
+		token:      token,
+		bufferSize: bufferSize,
+	}
+}
+
+// Replicate pushes the current content of the file to every peer in parallel.
+func (r *Replicator) Replicate(ctx context.Context, file fileio.File, peers []string) []Status {
+	return r.pushAll(ctx, file, peers, func(string) []string {
+		if r.host == "" {
+			return nil
+		}
+		return []string{r.host}
+	})
+}
+
+// Move pushes the file to the peers taking it over from this node, every peer records the others as its replicas.
+func (r *Replicator) Move(ctx context.Context, file fileio.File, peers []string) []Status {
+	return r.pushAll(ctx, file, peers, func(peer string) []string {
+		return slices.DeleteFunc(slices.Clone(peers), func(p string) bool { return p == peer })
+	})
+}
+
+func (r *Replicator) pushAll(ctx context.Context, file fileio.File, peers []string, replicas func(peer string) []string) []Status {
+	statuses := make([]Status, len(peers))
+
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			statuses[i] = Status{Host: peer, Acknowledged: true}
+			if err := r.push(ctx, peer, file, replicas(peer)); err != nil {
+				statuses[i] = Status{Host: peer, Err: err.Error()}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return statuses
+}
+
+// Push streams the file to the peer, which acknowledges it once the content matches the checksum.
+func (r *Replicator) Push(ctx context.Context, peer string, file fileio.File) error {
+	var replicas []string
+	if r.host != "" {
+		replicas = []string{r.host}
+	}
+
+	return r.push(ctx, peer, file, replicas)
+}
+
+func (r *Replicator) push(ctx context.Context, peer string, file fileio.File, replicas []string) error {
+	reader, err := file.Reader(r.bufferSize)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	// the reader fails if the file is rewritten after it has been opened, so the metadata can't be newer than the content
+	metadata := file.Metadata()
+	metadata.Replicas = replicas
+	metadata.Erasure = nil
+	if metadata.Checksum == "" {
+		if metadata.Checksum, err = fileio.Checksum(reader); err != nil {
+			return err
+		}
+		if _, err := reader.Seek(0, 0); err != nil {
+			return err
+		}
+	}
+
+	rawMetadata, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	link, err := url.JoinPath(peer, ReplicasPath, file.ID().String())
+	if err != nil {
+		return err
+	}
+
+	result, err := r.client.R().
+		SetContext(ctx).
+		SetAuthScheme("Bearer").
+		SetAuthToken(r.token).
+		SetHeader(ChecksumHeader, metadata.Checksum).
+		SetHeader(SizeHeader, strconv.FormatInt(file.Size(), 10)).
+		SetHeader(MetadataHeader, string(rawMetadata)).
+		SetBody(reader).
+		Put(link)
+	if err != nil {
+		return err
+	}
+	if result.IsError() {
+		return fmt.Errorf("peer %s responded with %s", peer, result.Status())
+	}
+
+	return nil
+}
+
+// Fetch streams the replica of the file stored by the peer, it must be verified against metadata.Checksum.
+func (r *Replicator) Fetch(ctx context.Context, peer string, fileID uuid.UUID) (body io.ReadCloser, size int64, metadata fileio.Metadata, err error) {
+	link, err := url.JoinPath(peer, ReplicasPath, fileID.String())
+	if err != nil {
+		return nil, 0, metadata, err
+	}
+
+	result, err := r.client.R().
+		SetContext(ctx).
+		SetAuthScheme("Bearer").
+		SetAuthToken(r.token).
+		SetDoNotParseResponse(true).
+		Get(link)
+	if err != nil {
+		return nil, 0, metadata, err
+	}
+	body = result.RawBody()
+	if result.IsError() {
+		body.Close()
+		return nil, 0, metadata, fmt.Errorf("peer %s responded with %s", peer, result.Status())
+	}
+
+	size, err = strconv.ParseInt(result.Header().Get(SizeHeader), 10, 64)
+	if err != nil {
+		body.Close()
+		return nil, 0, metadata, fmt.Errorf("peer %s sent invalid size: %w", peer, err)
+	}
+
+	if raw := result.Header().Get(MetadataHeader); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &metadata); err != nil {
+			body.Close()
+			return nil, 0, metadata, fmt.Errorf("peer %s sent invalid metadata: %w", peer, err)
+		}
+	}
+	metadata.Checksum = result.Header().Get(ChecksumHeader)
+
+	return body, size, metadata, nil
+}
@@ -0,0 +1,187 @@
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query parameters of signed urls, the other parameters aren't signed.
+const (
+	OperationParam = "op"
+	ExpiresParam   = "expires"
+	IPParam        = "ip"
+	RangeParam     = "range"
+	KeyIDParam     = "kid"
+	SignatureParam = "signature"
+)
+
+// version prefixes the signed string, so the format can be changed without accepting urls of the previous one
+const version = "v1"
+
+type Operation string
+
+const ReadOperation Operation = "read"
+
+var (
+	ErrNoKeys           = errors.New("no signing keys")
+	ErrMalformed        = errors.New("signed url is malformed")
+	ErrUnknownKey       = errors.New("signing key is unknown")
+	ErrInvalidSignature = errors.New("signature is invalid")
+	ErrExpired          = errors.New("signed url has expired")
+	ErrNotAllowed       = errors.New("signed url doesn't allow the request")
+)
+
+// Range is the part of the file the url is limited to, from First to Last byte inclusive.
+type Range struct {
+	First int64
+	Last  int64
+}
+
+func (r Range) String() string {
+	return strconv.FormatInt(r.First, 10) + "-" + strconv.FormatInt(r.Last, 10)
+}
+
+// Claims are the facts attested by the signature of the url.
+type Claims struct {
+	FileID    uuid.UUID
+	Operation Operation
+	ExpiresAt time.Time
+	// IP binds the url to a single client if it is valid
+	IP netip.Addr
+	// Range limits the url to a part of the file if it is set
+	Range *Range
+}
+
+// Signer mints and verifies self-contained urls with HMAC-SHA256, accepting several keys to let them be rotated.
+type Signer struct {
+	keys    map[string][]byte
+	current string
+}
+
+// NewSigner creates a signer accepting keys by their ids, urls are signed with the key of the current id.
+func NewSigner(keys map[string]string, current string) (*Signer, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	s := &Signer{keys: make(map[string][]byte, len(keys)), current: current}
+	for id, secret := range keys {
+		if secret == "" {
+			return nil, fmt.Errorf("signing key %q is empty", id)
+		}
+		s.keys[id] = []byte(secret)
+		if len(keys) == 1 && current == "" {
+			// the only key signs urls even if its id isn't repeated as the current one
+			s.current = id
+		}
+	}
+	if _, ok := s.keys[s.current]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, current)
+	}
+
+	return s, nil
+}
+
+// Sign returns the query parameters of the url granting the claims.
+func (s *Signer) Sign(claims Claims) url.Values {
+	query := url.Values{}
+	query.Set(OperationParam, string(claims.Operation))
+	query.Set(ExpiresParam, strconv.FormatInt(claims.ExpiresAt.Unix(), 10))
+	if claims.IP.IsValid() {
+		query.Set(IPParam, claims.IP.String())
+	}
+	if claims.Range != nil {
+		query.Set(RangeParam, claims.Range.String())
+	}
+	if s.current != "" {
+		query.Set(KeyIDParam, s.current)
+	}
+	query.Set(SignatureParam, base64.RawURLEncoding.EncodeToString(sign(s.keys[s.current], claims.FileID.String(), query)))
+
+	return query
+}
+
+// Verify checks that the url has been signed for the operation on the file by the client and returns its claims.
+func (s *Signer) Verify(fileID uuid.UUID, operation Operation, query url.Values, client netip.Addr, now time.Time) (Claims, error) {
+	claims := Claims{FileID: fileID, Operation: Operation(query.Get(OperationParam))}
+
+	key, ok := s.keys[query.Get(KeyIDParam)]
+	if !ok {
+		return claims, ErrUnknownKey
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(query.Get(SignatureParam))
+	if err != nil {
+		return claims, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	if !hmac.Equal(signature, sign(key, fileID.String(), query)) {
+		return claims, ErrInvalidSignature
+	}
+
+	// the parameters are trusted from now on, but still can't be parsed if they have been signed that way
+	expires, err := strconv.ParseInt(query.Get(ExpiresParam), 10, 64)
+	if err != nil {
+		return claims, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	claims.ExpiresAt = time.Unix(expires, 0)
+	if raw := query.Get(IPParam); raw != "" {
+		if claims.IP, err = netip.ParseAddr(raw); err != nil {
+			return claims, fmt.Errorf("%w: %w", ErrMalformed, err)
+		}
+	}
+	if raw := query.Get(RangeParam); raw != "" {
+		if claims.Range, err = parseRange(raw); err != nil {
+			return claims, err
+		}
+	}
+
+	if !now.Before(claims.ExpiresAt) {
+		return claims, ErrExpired
+	}
+	if claims.Operation != operation {
+		return claims, fmt.Errorf("%w: operation %q", ErrNotAllowed, claims.Operation)
+	}
+	if claims.IP.IsValid() && claims.IP.Unmap() != client.Unmap() {
+		return claims, fmt.Errorf("%w: client %s", ErrNotAllowed, client)
+	}
+
+	return claims, nil
+}
+
+// sign computes the signature of the signed parameters, every one of them is a separate line of the signed string.
+func sign(key []byte, fileID string, query url.Values) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.Join([]string{
+		version,
+		fileID,
+		query.Get(OperationParam),
+		query.Get(ExpiresParam),
+		query.Get(IPParam),
+		query.Get(RangeParam),
+		query.Get(KeyIDParam),
+	}, "\n")))
+
+	return mac.Sum(nil)
+}
+
+func parseRange(raw string) (*Range, error) {
+	rawFirst, rawLast, ok := strings.Cut(raw, "-")
+	first, err := strconv.ParseInt(rawFirst, 10, 64)
+	if err != nil || !ok {
+		return nil, fmt.Errorf("%w: range %q", ErrMalformed, raw)
+	}
+	last, err := strconv.ParseInt(rawLast, 10, 64)
+	if err != nil || first < 0 || last < first {
+		return nil, fmt.Errorf("%w: range %q", ErrMalformed, raw)
+	}
+
+	return &Range{First: first, Last: last}, nil
+}
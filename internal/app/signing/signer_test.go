@@ -0,0 +1,89 @@
+package signing
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maps"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestSigner_Verify(t *testing.T) {
+	signer, err := NewSigner(map[string]string{"old": "old secret", "new": "new secret"}, "new")
+	require.NoError(t, err)
+
+	fileID := uuid.New()
+	now := time.Now()
+	client := netip.MustParseAddr("203.0.113.7")
+	claims := Claims{
+		FileID:    fileID,
+		Operation: ReadOperation,
+		ExpiresAt: now.Add(time.Minute),
+		IP:        client,
+		Range:     &Range{First: 10, Last: 19},
+	}
+	query := signer.Sign(claims)
+	assert.Equal(t, "new", query.Get(KeyIDParam))
+
+	verified, err := signer.Verify(fileID, ReadOperation, query, client, now)
+	require.NoError(t, err)
+	assert.Equal(t, claims.ExpiresAt.Unix(), verified.ExpiresAt.Unix())
+	assert.Equal(t, claims.IP, verified.IP)
+	assert.Equal(t, claims.Range, verified.Range)
+
+	t.Run("rejected", func(t *testing.T) {
+		_, err := signer.Verify(uuid.New(), ReadOperation, query, client, now)
+		assert.ErrorIs(t, err, ErrInvalidSignature, "another file")
+
+		_, err = signer.Verify(fileID, ReadOperation, query, client, now.Add(time.Hour))
+		assert.ErrorIs(t, err, ErrExpired)
+
+		_, err = signer.Verify(fileID, ReadOperation, query, netip.MustParseAddr("203.0.113.8"), now)
+		assert.ErrorIs(t, err, ErrNotAllowed, "another client")
+
+		_, err = signer.Verify(fileID, "write", query, client, now)
+		assert.ErrorIs(t, err, ErrNotAllowed, "another operation")
+
+		tampered := maps.Clone(query)
+		tampered.Set(RangeParam, "0-1000")
+		_, err = signer.Verify(fileID, ReadOperation, tampered, client, now)
+		assert.ErrorIs(t, err, ErrInvalidSignature, "tampered range")
+
+		tampered = maps.Clone(query)
+		tampered.Del(IPParam)
+		_, err = signer.Verify(fileID, ReadOperation, tampered, client, now)
+		assert.ErrorIs(t, err, ErrInvalidSignature, "unbound client")
+
+		tampered = maps.Clone(query)
+		tampered.Set(KeyIDParam, "unknown")
+		_, err = signer.Verify(fileID, ReadOperation, tampered, client, now)
+		assert.ErrorIs(t, err, ErrUnknownKey)
+	})
+
+	t.Run("rotated keys", func(t *testing.T) {
+		old, err := NewSigner(map[string]string{"old": "old secret"}, "")
+		require.NoError(t, err)
+		query := old.Sign(Claims{FileID: fileID, Operation: ReadOperation, ExpiresAt: now.Add(time.Minute)})
+
+		_, err = signer.Verify(fileID, ReadOperation, query, client, now)
+		assert.NoError(t, err, "urls signed with the previous key are still accepted")
+
+		rotated, err := NewSigner(map[string]string{"new": "new secret"}, "new")
+		require.NoError(t, err)
+		_, err = rotated.Verify(fileID, ReadOperation, query, client, now)
+		assert.ErrorIs(t, err, ErrUnknownKey, "removed key is not accepted")
+	})
+}
+
+func TestNewSigner(t *testing.T) {
+	_, err := NewSigner(nil, "")
+	assert.ErrorIs(t, err, ErrNoKeys)
+
+	_, err = NewSigner(map[string]string{"a": "secret", "b": "secret"}, "")
+	assert.ErrorIs(t, err, ErrUnknownKey, "current key must be chosen among several")
+
+	_, err = NewSigner(map[string]string{"a": ""}, "a")
+	assert.Error(t, err)
+}
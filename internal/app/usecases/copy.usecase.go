@@ -0,0 +1,44 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/StratuStore/file-storage/internal/app/fileio"
+	"github.com/google/uuid"
+	"time"
+)
+
+// CopyFile supposed to be a request from FileSystem Manager
+func (u *UseCases) CopyFile(ctx context.Context, fileID, copyID uuid.UUID, ownerID string, expiresAt time.Time) (size int64, checksum string, err error) {
+	if fileID == copyID {
+		return 0, "", newErrorWithMessage("file can't be copied into itself")
+	}
+
+	file, err := u.StorageController.File(fileID)
+	if err != nil {
+		return 0, "", err
+	}
+
+	// the checksum of the original is taken over by the copy, so its content doesn't have to be hashed again
+	if _, err := u.checksum(file); err != nil {
+		return 0, "", err
+	}
+
+	if ownerID == "" {
+		ownerID = file.Metadata().OwnerID
+	}
+	copied, reservation, err := u.StorageController.AddReservedFile(copyID, file.Size(), fileio.Metadata{
+		OwnerID:   ownerID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return 0, "", err
+	}
+
+	if err := fileio.Copy(copied, file, reservation); err != nil {
+		return 0, "", fmt.Errorf("unable to copy file: %w", errors.Join(err, u.DeleteFile(ctx, copyID)))
+	}
+
+	return copied.Size(), copied.Metadata().Checksum, nil
+}
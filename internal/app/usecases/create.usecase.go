@@ -2,18 +2,25 @@ package usecases
 
 import (
 	"context"
+	"github.com/StratuStore/file-storage/internal/app/fileio"
 	"github.com/google/uuid"
+	"time"
 )
 
 // CreateFile supposed to be a request from FileSystem Manager via Kafka
-func (u *UseCases) CreateFile(ctx context.Context, host string, fileID uuid.UUID) (connectionID uuid.UUID, err error) {
-	file, err := u.StorageController.AddFile(fileID)
+func (u *UseCases) CreateFile(ctx context.Context, host string, fileID uuid.UUID, size int64, ownerID string, expiresAt time.Time, peers []string) (connectionID uuid.UUID, err error) {
+	file, reservation, err := u.StorageController.AddReservedFile(fileID, size, fileio.Metadata{
+		OwnerID:   ownerID,
+		ExpiresAt: expiresAt,
+	})
 	if err != nil {
 		return connectionID, err
 	}
 
 	return u.FilesConnector.OpenConnection(&FileWithHost{
-		File: file,
-		Host: host,
+		File:        file,
+		Host:        host,
+		Reservation: reservation,
+		Peers:       peers,
 	})
 }
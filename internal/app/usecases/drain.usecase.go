@@ -0,0 +1,102 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/StratuStore/file-storage/internal/app/fileio"
+	"github.com/google/uuid"
+	"log/slog"
+)
+
+// StartDrain supposed to be a request from the operator decommissioning the node
+func (u *UseCases) StartDrain(ctx context.Context) (files int, err error) {
+	u.StorageController.SetDraining(true)
+	ids := u.StorageController.FileIDs()
+	u.l.Warn("node is draining", slog.Int("files", len(ids)))
+
+	if u.Notifier == nil {
+		return len(ids), nil
+	}
+
+	return len(ids), u.Notifier.NotifyDraining(ctx, true, ids)
+}
+
+// StopDrain supposed to be a request from the operator
+func (u *UseCases) StopDrain(ctx context.Context) error {
+	u.StorageController.SetDraining(false)
+	u.l.Info("node has stopped draining")
+
+	if u.Notifier == nil {
+		return nil
+	}
+
+	return u.Notifier.NotifyDraining(ctx, false, nil)
+}
+
+// MigrateFile supposed to be a request from FileSystem Manager
+func (u *UseCases) MigrateFile(ctx context.Context, fileID uuid.UUID, targets []string) error {
+	u.migrationMx.Lock()
+	defer u.migrationMx.Unlock()
+
+	l := u.l.With(slog.String("op", "MigrateFile"), slog.String("id", fileID.String()))
+
+	if u.Notifier == nil {
+		return newErrorWithMessage("fsm is not reachable")
+	}
+	if len(targets) == 0 {
+		return newErrorWithMessage("no target nodes")
+	}
+
+	file, err := u.StorageController.File(fileID)
+	if err != nil {
+		return err
+	}
+
+	checksum, err := u.checksum(file)
+	if err != nil {
+		return err
+	}
+
+	statuses := u.Replicator.Move(ctx, file, targets)
+	for _, status := range statuses {
+		if !status.Acknowledged {
+			err = errors.Join(err, fmt.Errorf("%s: %s", status.Host, status.Err))
+		}
+	}
+	// the targets have verified the content against the checksum, which must still be the one of the file
+	if err == nil && file.Metadata().Checksum != checksum {
+		err = newErrorWithMessage("file has been changed during migration")
+	}
+	if err != nil {
+		l.Warn("unable to migrate file", slog.String("err", err.Error()))
+		return errors.Join(err, u.Notifier.NotifyMigrated(ctx, fileID, statuses, err))
+	}
+
+	if err := u.Notifier.NotifyMigrated(ctx, fileID, statuses, nil); err != nil {
+		return fmt.Errorf("fsm hasn't taken the file over: %w", err)
+	}
+	l.Info("file has been migrated", slog.Any("targets", targets))
+
+	return u.DeleteFile(ctx, fileID)
+}
+
+// checksum returns the checksum of the file, computing and storing it for the files written before checksums were kept.
+func (u *UseCases) checksum(file fileio.File) (string, error) {
+	metadata := file.Metadata()
+	if metadata.Checksum != "" {
+		return metadata.Checksum, nil
+	}
+
+	reader, err := file.Reader(u.MaxBufferSize)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	if metadata.Checksum, err = fileio.Checksum(reader); err != nil {
+		return "", err
+	}
+
+	return metadata.Checksum, file.SetMetadata(metadata)
+}
@@ -0,0 +1,134 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/StratuStore/file-storage/internal/app/fileio"
+	"github.com/google/uuid"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// ErrIngestCancelled is the cause of the ingest cancelled by CancelIngest, such ingests aren't reported to the FSM.
+var ErrIngestCancelled = errors.New("ingest has been cancelled")
+
+// Downloader fetches content from outside of the cluster.
+type Downloader interface {
+	// Validate rejects sources which can't be downloaded without connecting to them.
+	Validate(link string) error
+	// Download returns the content of the source, the size is -1 if it is unknown.
+	Download(ctx context.Context, link string) (body io.ReadCloser, size int64, err error)
+}
+
+// IngestFile supposed to be a request from FileSystem Manager
+func (u *UseCases) IngestFile(ctx context.Context, fileID uuid.UUID, source string, limit int64, ownerID string, expiresAt time.Time, peers []string) error {
+	if u.Downloader == nil {
+		return newErrorWithMessage("ingest is disabled on the node")
+	}
+	if limit <= 0 {
+		return newErrorWithMessage("size limit of the ingested file is required")
+	}
+	if err := u.Downloader.Validate(source); err != nil {
+		return err
+	}
+
+	file, reservation, err := u.StorageController.AddReservedFile(fileID, limit, fileio.Metadata{
+		OwnerID:   ownerID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the download outlives the request, it is only cancelled by CancelIngest
+	ctx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	u.ingests.Store(fileID, cancel)
+	go func() {
+		defer cancel(nil)
+		l := u.l.With(slog.String("op", "IngestFile"), slog.String("id", fileID.String()))
+
+		size, checksum, err := u.ingest(ctx, file, reservation, source, limit)
+		// whoever removes the ingest first is in charge of it, so a late cancellation deletes the ingested file as well
+		if _, ok := u.ingests.LoadAndDelete(fileID); !ok {
+			err = ErrIngestCancelled
+		}
+		if err != nil {
+			err = fmt.Errorf("unable to ingest file: %w", errors.Join(err, u.DeleteFile(context.Background(), fileID)))
+		}
+
+		if errors.Is(err, ErrIngestCancelled) {
+			l.Info("ingest has been cancelled")
+			return
+		}
+		if err != nil {
+			l.Error("unable to ingest file", slog.String("err", err.Error()))
+		} else if len(peers) > 0 {
+			go u.replicate(file, peers)
+		}
+
+		if u.Notifier == nil {
+			return
+		}
+		if err := u.Notifier.NotifyIngested(context.Background(), fileID, size, checksum, err); err != nil {
+			l.Error("unable to notify fsm about ingest", slog.String("err", err.Error()))
+		}
+	}()
+
+	return nil
+}
+
+// CancelIngest stops the download of the file and deletes it, the file is deleted as well if it has already been ingested.
+func (u *UseCases) CancelIngest(ctx context.Context, fileID uuid.UUID) error {
+	if cancel, ok := u.ingests.LoadAndDelete(fileID); ok {
+		// the ingest deletes the file itself, since it is locked until the download stops
+		cancel.(context.CancelCauseFunc)(ErrIngestCancelled)
+		return nil
+	}
+
+	if err := u.DeleteFile(ctx, fileID); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (u *UseCases) ingest(ctx context.Context, file fileio.File, reservation fileio.Reservation, source string, limit int64) (size int64, checksum string, err error) {
+	body, size, err := u.Downloader.Download(ctx, source)
+	if err != nil {
+		reservation.Release()
+		return 0, "", err
+	}
+	defer body.Close()
+
+	if size > limit {
+		reservation.Release()
+		return 0, "", newErrorWithMessage(fmt.Sprintf("source is larger than %d bytes", limit))
+	}
+
+	writer, err := file.Writer(reservation)
+	if err != nil {
+		reservation.Release()
+		return 0, "", err
+	}
+
+	reader := &contextReader{body, ctx}
+	n, err := io.Copy(writer, io.LimitReader(reader, limit))
+	if err == nil && n == limit {
+		// the content must end right at the limit
+		if extra, _ := io.ReadFull(reader, make([]byte, 1)); extra > 0 {
+			err = newErrorWithMessage(fmt.Sprintf("source is larger than %d bytes", limit))
+		}
+	}
+	if err == nil && size >= 0 && n != size {
+		err = io.ErrUnexpectedEOF
+	}
+	// the writer releases the part of the reservation which hasn't been consumed
+	if err := errors.Join(err, writer.Close()); err != nil {
+		return 0, "", err
+	}
+
+	return n, file.Metadata().Checksum, nil
+}
@@ -0,0 +1,129 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"github.com/StratuStore/file-storage/internal/app/connector"
+	"github.com/StratuStore/file-storage/internal/app/controller"
+	"github.com/StratuStore/file-storage/internal/app/ingest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+type ingestResult struct {
+	size     int64
+	checksum string
+	err      error
+}
+
+// ingestNotifier reports ingests only, the other notifications aren't sent by them.
+type ingestNotifier struct {
+	Notifier
+	results chan ingestResult
+}
+
+func (n *ingestNotifier) NotifyIngested(_ context.Context, _ uuid.UUID, size int64, checksum string, ingestErr error) error {
+	n.results <- ingestResult{size, checksum, ingestErr}
+
+	return nil
+}
+
+func TestIngest_DownloadsSource(t *testing.T) {
+	content := bytes.Repeat([]byte("ingested content "), 100)
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/content", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(content)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		_, _ = w.Write(content[:10])
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	source := httptest.NewServer(mux)
+	defer source.Close()
+	defer close(release)
+
+	layout, err := controller.NewLayout(2, 2)
+	require.NoError(t, err)
+	placement, err := controller.NewPlacement(controller.FreeSpacePlacement)
+	require.NoError(t, err)
+	ctrl, err := controller.NewController([]*controller.Disk{controller.NewDisk(t.TempDir(), 1<<20)}, layout, placement, controller.Watermarks{Low: 1, High: 1})
+	require.NoError(t, err)
+
+	u := NewUseCases(connector.NewConnector[*FileWithHost](), connector.NewConnector[Reader](), ctrl, nil, nil, slog.New(slog.DiscardHandler), 512, 4096, false)
+	notifier := &ingestNotifier{results: make(chan ingestResult, 1)}
+	u.Notifier = notifier
+	u.Downloader = ingest.New(ingest.Options{Timeout: 5 * time.Second, AllowPrivate: true})
+	ctx := context.Background()
+
+	receive := func() ingestResult {
+		select {
+		case result := <-notifier.results:
+			return result
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "ingest hasn't been reported")
+			return ingestResult{}
+		}
+	}
+
+	t.Run("ingested", func(t *testing.T) {
+		id := uuid.New()
+		require.NoError(t, u.IngestFile(ctx, id, source.URL+"/content", 1<<16, "owner", time.Time{}, nil))
+
+		result := receive()
+		require.NoError(t, result.err)
+		assert.Equal(t, int64(len(content)), result.size)
+
+		file, err := ctrl.File(id)
+		require.NoError(t, err)
+		assert.Equal(t, result.checksum, file.Metadata().Checksum)
+		assert.Equal(t, "owner", file.Metadata().OwnerID)
+
+		connectionID, err := u.OpenFile(ctx, id)
+		require.NoError(t, err)
+		reader, err := u.Read(ctx, connectionID)
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, content, data)
+	})
+
+	t.Run("larger than limit", func(t *testing.T) {
+		id := uuid.New()
+		require.NoError(t, u.IngestFile(ctx, id, source.URL+"/content", int64(len(content)-1), "", time.Time{}, nil))
+
+		assert.Error(t, receive().err)
+		_, err := ctrl.File(id)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		id := uuid.New()
+		require.NoError(t, u.IngestFile(ctx, id, source.URL+"/slow", 100, "", time.Time{}, nil))
+		require.NoError(t, u.CancelIngest(ctx, id))
+
+		require.Eventually(t, func() bool {
+			_, err := ctrl.File(id)
+			return err != nil
+		}, 5*time.Second, 10*time.Millisecond, "cancelled ingest must be deleted")
+		assert.Empty(t, notifier.results, "cancelled ingest must not be reported")
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		assert.Error(t, u.IngestFile(ctx, uuid.New(), "ftp://example.com/file", 100, "", time.Time{}, nil))
+		assert.Error(t, u.IngestFile(ctx, uuid.New(), source.URL+"/content", 0, "", time.Time{}, nil))
+	})
+}
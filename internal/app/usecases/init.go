@@ -1,48 +1,68 @@
 package usecases
 
 import (
+	"context"
 	"github.com/StratuStore/file-storage/internal/app/fileio"
-	"github.com/go-resty/resty/v2"
 	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
 	"io"
 	"log/slog"
+	"sync"
 )
 
 type UseCases struct {
 	FilesConnector    Connector[*FileWithHost]
 	ReadersConnector  Connector[Reader]
 	StorageController StorageController
-	MaxBufferSize     int
-	MinBufferSize     int
-	l                 *slog.Logger
-	serviceToken      string
-	client            *resty.Client
+	Replicator        Replicator
+	FSM               FSMClient
+	// Notifier reports to the FSM, it is set once the queue handler is created
+	Notifier Notifier
+	// Downloader fetches ingested files, ingest is disabled if it isn't set
+	Downloader      Downloader
+	MaxBufferSize   int
+	MinBufferSize   int
+	PromoteOnAccess bool
+	l               *slog.Logger
+	repairs         singleflight.Group
+	migrationMx     sync.Mutex
+	// ingests hold the cancel functions of the downloads in progress
+	ingests sync.Map
 }
 
 func NewUseCases(
 	filesConnector Connector[*FileWithHost],
 	readersConnector Connector[Reader],
 	storageController StorageController,
+	replicator Replicator,
+	fsmClient FSMClient,
 	logger *slog.Logger,
 	minBufferSize int,
 	maxBufferSize int,
-	serviceToken string,
+	promoteOnAccess bool,
 ) *UseCases {
 	return &UseCases{
 		FilesConnector:    filesConnector,
 		ReadersConnector:  readersConnector,
 		StorageController: storageController,
+		Replicator:        replicator,
+		FSM:               fsmClient,
 		MinBufferSize:     minBufferSize,
 		MaxBufferSize:     maxBufferSize,
-		serviceToken:      serviceToken,
-		client:            resty.New(),
+		PromoteOnAccess:   promoteOnAccess,
 		l:                 logger.With(slog.String("op", "internal.app.usecases.UseCases")),
 	}
 }
 
+// FSMClient makes callbacks to the FSM which has sent the request, retrying transient failures.
+type FSMClient interface {
+	Delete(ctx context.Context, link, key string) error
+}
+
 type Connector[V Closeder] interface {
 	OpenConnection(value V) (uuid.UUID, error)
 	Connection(id uuid.UUID) (V, error)
+	Len() int
 }
 
 type Closeder interface {
@@ -55,22 +75,41 @@ type Reader interface {
 }
 
 type FileWithHost struct {
-	File fileio.File
-	Host string
+	File        fileio.File
+	Host        string
+	Reservation fileio.Reservation
+	// Peers are hosts of the nodes the file is replicated to once it is written
+	Peers []string
 }
 
 func (f *FileWithHost) Writer() (io.WriteCloser, error) {
-	return f.File.Writer()
+	return f.File.Writer(f.Reservation)
 }
 
+// Closed keeps the connection until its reservation is released, even if the file has been deleted.
 func (f *FileWithHost) Closed() bool {
-	return f.File.Closed()
+	return f.File.Closed() && (f.Reservation == nil || f.Reservation.Released())
+}
+
+// Close releases the space reserved for the upload when the connection is disposed.
+func (f *FileWithHost) Close() error {
+	if f.Reservation != nil {
+		f.Reservation.Release()
+	}
+
+	return nil
 }
 
 type StorageController interface {
 	AddFile(id uuid.UUID) (fileio.File, error)
+	AddReservedFile(id uuid.UUID, size int64, metadata fileio.Metadata) (fileio.File, fileio.Reservation, error)
+	ReserveFile(id uuid.UUID, size int64) (fileio.Reservation, error)
 	DeleteFile(id uuid.UUID) error
 	File(id uuid.UUID) (fileio.File, error)
+	Promote(id uuid.UUID) error
+	FileIDs() []uuid.UUID
+	Draining() bool
+	SetDraining(draining bool)
 }
 
 type ErrorWithMessage interface {
@@ -2,22 +2,60 @@ package usecases
 
 import (
 	"context"
+	"errors"
+	"github.com/StratuStore/file-storage/internal/app/fileio"
 	"github.com/google/uuid"
+	"log/slog"
+	"os"
 )
 
 // OpenFile supposed to be a request from FileSystem Manager via Kafka
 func (u *UseCases) OpenFile(ctx context.Context, fileID uuid.UUID) (connectionID uuid.UUID, err error) {
-	file, err := u.StorageController.File(fileID)
+	reader, err := u.openReader(ctx, fileID)
 	if err != nil {
 		return connectionID, err
 	}
 
+	return u.ReadersConnector.OpenConnection(reader)
+}
+
+// ReadFile supposed to be a request from user directly with a url signed by FileSystem Manager
+func (u *UseCases) ReadFile(ctx context.Context, fileID uuid.UUID) (reader Reader, err error) {
+	if reader, err = u.openReader(ctx, fileID); errors.Is(err, fileio.ErrBusy) {
+		return nil, newErrorWithMessage("file is busy")
+	}
+
+	return reader, err
+}
+
+// openReader opens the file for reading, repairing its missing blob from replicas, and records the access.
+func (u *UseCases) openReader(ctx context.Context, fileID uuid.UUID) (Reader, error) {
+	file, err := u.StorageController.File(fileID)
+	if err != nil {
+		return nil, err
+	}
+
 	bufferSize := max(min(u.MaxBufferSize, int(file.Size())), u.MinBufferSize)
 
 	reader, err := file.Reader(bufferSize)
+	if errors.Is(err, os.ErrNotExist) {
+		u.l.Warn("blob of the file is missing", slog.String("id", fileID.String()))
+		if err = u.Repair(ctx, fileID); err == nil {
+			reader, err = file.Reader(bufferSize)
+		}
+	}
 	if err != nil {
-		return connectionID, err
+		return nil, err
 	}
 
-	return u.ReadersConnector.OpenConnection(reader)
+	file.Touch()
+	if u.PromoteOnAccess {
+		go func() {
+			if err := u.StorageController.Promote(fileID); err != nil {
+				u.l.Debug("unable to promote file to the hot tier", slog.String("id", fileID.String()), slog.String("err", err.Error()))
+			}
+		}()
+	}
+
+	return newRepairingReader(u, file, reader, bufferSize), nil
 }
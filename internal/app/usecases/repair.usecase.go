@@ -0,0 +1,172 @@
+package usecases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"expvar"
+	"fmt"
+	"github.com/StratuStore/file-storage/internal/app/fileio"
+	"github.com/google/uuid"
+	"hash"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// repairs counts repairs of files from their replicas, it is published with the other expvar metrics
+var repairs = expvar.NewMap("repairs")
+
+// Repair replaces the local copy of the file by the first replica which matches its checksum.
+func (u *UseCases) Repair(ctx context.Context, fileID uuid.UUID) error {
+	_, err, _ := u.repairs.Do(fileID.String(), func() (any, error) {
+		return nil, u.repair(ctx, fileID)
+	})
+
+	return err
+}
+
+func (u *UseCases) repair(ctx context.Context, fileID uuid.UUID) error {
+	l := u.l.With(slog.String("op", "repair"), slog.String("id", fileID.String()))
+
+	file, err := u.StorageController.File(fileID)
+	if err != nil {
+		return err
+	}
+
+	metadata := file.Metadata()
+	if len(metadata.Replicas) == 0 {
+		repairs.Add("failed", 1)
+		l.Error("unable to repair file without replicas")
+		return newErrorWithMessage("file is damaged and has no replicas")
+	}
+
+	var errs error
+	for _, peer := range metadata.Replicas {
+		err := u.repairFrom(ctx, file, peer, metadata)
+		if err == nil {
+			repairs.Add("succeeded", 1)
+			l.Warn("file has been repaired from replica", slog.String("host", peer))
+			return nil
+		}
+		errs = errors.Join(errs, fmt.Errorf("replica at %s: %w", peer, err))
+	}
+
+	repairs.Add("failed", 1)
+	l.Error("unable to repair file", slog.String("err", errs.Error()))
+
+	return fmt.Errorf("unable to repair file: %w", errs)
+}
+
+// repairFrom replaces the local copy by the replica of the peer once it has been verified.
+func (u *UseCases) repairFrom(ctx context.Context, file fileio.File, peer string, metadata fileio.Metadata) error {
+	body, size, replica, err := u.Replicator.Fetch(ctx, peer, file.ID())
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if metadata.Checksum != "" && replica.Checksum != metadata.Checksum {
+		return fmt.Errorf("replica is outdated: %w", fileio.ErrChecksumMismatch)
+	}
+
+	reservation, err := u.StorageController.ReserveFile(file.ID(), size)
+	if err != nil {
+		return err
+	}
+
+	return file.Replace(reservation, &contextReader{body, ctx}, size, replica.Checksum)
+}
+
+// repairingReader verifies the file while it is read and repairs it from replicas on failure.
+type repairingReader struct {
+	Reader
+	u          *UseCases
+	file       fileio.File
+	bufferSize int
+	// hash covers the first hashed bytes, it is only extended while the reader doesn't jump over them
+	hash     hash.Hash
+	hashed   int64
+	pos      int64
+	repaired bool
+}
+
+func newRepairingReader(u *UseCases, file fileio.File, reader Reader, bufferSize int) *repairingReader {
+	return &repairingReader{
+		Reader:     reader,
+		u:          u,
+		file:       file,
+		bufferSize: bufferSize,
+		hash:       sha256.New(),
+	}
+}
+
+func (r *repairingReader) Read(p []byte) (n int, err error) {
+	n, err = r.Reader.Read(p)
+	if r.pos == r.hashed {
+		r.hash.Write(p[:n])
+		r.hashed += int64(n)
+	}
+	r.pos += int64(n)
+
+	if err != nil && err != io.EOF && !errors.Is(err, os.ErrClosed) && !r.repaired {
+		r.repaired = true
+		if err2 := r.reopen(); err2 != nil {
+			return n, errors.Join(err, err2)
+		}
+		if n > 0 {
+			return n, nil
+		}
+
+		return r.Read(p)
+	}
+
+	if r.hashed == r.file.Size() && n > 0 {
+		checksum := r.file.Metadata().Checksum
+		if checksum != "" && hex.EncodeToString(r.hash.Sum(nil)) != checksum {
+			// the content has already been served, the next readers get the repaired copy
+			r.repaired = true
+			return n, errors.Join(fileio.ErrChecksumMismatch, r.u.Repair(context.Background(), r.file.ID()))
+		}
+	}
+
+	return n, err
+}
+
+func (r *repairingReader) Seek(offset int64, whence int) (int64, error) {
+	pos, err := r.Reader.Seek(offset, whence)
+	if err == nil {
+		r.pos = pos
+	}
+	if err == nil && pos == 0 && r.hashed > 0 {
+		r.hash.Reset()
+		r.hashed = 0
+	}
+
+	return pos, err
+}
+
+// reopen repairs the file and replaces the reader by a reader of the repaired copy at the same position.
+func (r *repairingReader) reopen() error {
+	if err := r.u.Repair(context.Background(), r.file.ID()); err != nil {
+		return err
+	}
+
+	reader, err := r.file.Reader(r.bufferSize)
+	if err != nil {
+		return err
+	}
+	if _, err := reader.Seek(r.pos, io.SeekStart); err != nil {
+		reader.Close()
+		return err
+	}
+
+	r.Reader.Close()
+	r.Reader = reader
+	// the bytes hashed so far may come from the damaged copy, while the repaired one has been verified
+	r.hash.Reset()
+	r.hashed = -1
+
+	return nil
+}
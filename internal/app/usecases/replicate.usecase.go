@@ -0,0 +1,128 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/StratuStore/file-storage/internal/app/fileio"
+	"github.com/StratuStore/file-storage/internal/app/replication"
+	"github.com/google/uuid"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// StoreReplica supposed to be a request from another storage node
+func (u *UseCases) StoreReplica(ctx context.Context, fileID uuid.UUID, reader io.Reader, size int64, checksum string, metadata fileio.Metadata) (err error) {
+	if size < 0 {
+		return newErrorWithMessage("size of the replica is unknown")
+	}
+
+	file, err := u.StorageController.File(fileID)
+	var reservation fileio.Reservation
+	var created bool
+	switch {
+	case errors.Is(err, os.ErrNotExist) && u.StorageController.Draining():
+		return newErrorWithMessage("node is draining")
+	case errors.Is(err, os.ErrNotExist):
+		file, reservation, err = u.StorageController.AddReservedFile(fileID, size, metadata)
+		created = true
+	case err == nil:
+		reservation, err = u.StorageController.ReserveFile(fileID, size)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := file.Replace(reservation, &contextReader{reader, ctx}, size, checksum); err != nil {
+		// only the file created for the replica is deleted, an existing copy is left as it was
+		if created {
+			err = errors.Join(err, u.DeleteFile(ctx, fileID))
+		}
+		return fmt.Errorf("unable to store replica: %w", err)
+	}
+
+	// Replace has set the checksum, the replica keeps the hosts it can be repaired from
+	if metadata.Replicas == nil {
+		return nil
+	}
+	current := file.Metadata()
+	current.Replicas = metadata.Replicas
+
+	return file.SetMetadata(current)
+}
+
+// ReplicaReader supposed to be a request from another storage node
+func (u *UseCases) ReplicaReader(ctx context.Context, fileID uuid.UUID) (reader Reader, size int64, metadata fileio.Metadata, err error) {
+	file, err := u.StorageController.File(fileID)
+	if err != nil {
+		return nil, 0, metadata, err
+	}
+
+	reader, err = file.Reader(u.MaxBufferSize)
+	if err != nil {
+		return nil, 0, metadata, err
+	}
+
+	metadata = file.Metadata()
+	metadata.Replicas, metadata.Erasure = nil, nil
+	if metadata.Checksum == "" {
+		if metadata.Checksum, err = fileio.Checksum(reader); err == nil {
+			_, err = reader.Seek(0, io.SeekStart)
+		}
+		if err != nil {
+			reader.Close()
+			return nil, 0, metadata, err
+		}
+	}
+
+	return reader, file.Size(), metadata, nil
+}
+
+// replicate pushes the file to peers and records the ones which have acknowledged it
+func (u *UseCases) replicate(file fileio.File, peers []string) {
+	l := u.l.With(slog.String("op", "replicate"), slog.String("id", file.ID().String()))
+	ctx := context.Background()
+
+	statuses := u.Replicator.Replicate(ctx, file, peers)
+
+	var acknowledged []string
+	for _, status := range statuses {
+		if status.Acknowledged {
+			acknowledged = append(acknowledged, status.Host)
+		} else {
+			l.Warn("replica has not been acknowledged", slog.String("host", status.Host), slog.String("err", status.Err))
+		}
+	}
+
+	metadata := file.Metadata()
+	metadata.Replicas = acknowledged
+	if err := file.SetMetadata(metadata); err != nil {
+		l.Error("unable to record replicas", slog.String("err", err.Error()))
+	}
+
+	if u.Notifier == nil {
+		return
+	}
+	if err := u.Notifier.NotifyReplicated(ctx, file.ID(), statuses); err != nil {
+		l.Error("unable to notify fsm about replication", slog.String("err", err.Error()))
+	}
+}
+
+type Replicator interface {
+	Replicate(ctx context.Context, file fileio.File, peers []string) []replication.Status
+	Move(ctx context.Context, file fileio.File, peers []string) []replication.Status
+	Push(ctx context.Context, peer string, file fileio.File) error
+	// Fetch returns the content of the file stored by the peer, it must be verified against metadata.Checksum
+	Fetch(ctx context.Context, peer string, fileID uuid.UUID) (body io.ReadCloser, size int64, metadata fileio.Metadata, err error)
+}
+
+type Notifier interface {
+	NotifyReplicated(ctx context.Context, fileID uuid.UUID, statuses []replication.Status) error
+	// NotifyMigrated reports the file moved to the peers, or migrateErr if it has not been moved.
+	// The FSM takes the file over once it accepts the notification.
+	NotifyMigrated(ctx context.Context, fileID uuid.UUID, statuses []replication.Status, migrateErr error) error
+	NotifyDraining(ctx context.Context, draining bool, fileIDs []uuid.UUID) error
+	// NotifyIngested reports the size and the checksum of the ingested file, or ingestErr if it has not been ingested.
+	NotifyIngested(ctx context.Context, fileID uuid.UUID, size int64, checksum string, ingestErr error) error
+}
@@ -0,0 +1,62 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"github.com/StratuStore/file-storage/internal/app/fileio"
+	"github.com/google/uuid"
+	"slices"
+)
+
+type FileInfo struct {
+	ID       uuid.UUID
+	Size     int64
+	Metadata fileio.Metadata
+}
+
+// StatFile supposed to be a request from FileSystem Manager
+func (u *UseCases) StatFile(ctx context.Context, fileID uuid.UUID) (FileInfo, error) {
+	file, err := u.StorageController.File(fileID)
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	return FileInfo{ID: fileID, Size: file.Size(), Metadata: file.Metadata()}, nil
+}
+
+// ListFiles supposed to be a request from FileSystem Manager
+func (u *UseCases) ListFiles(ctx context.Context, after uuid.UUID, limit int) (files []FileInfo, more bool, err error) {
+	ids := u.StorageController.FileIDs()
+	slices.SortFunc(ids, compareIDs)
+
+	start := 0
+	if after != uuid.Nil {
+		var found bool
+		if start, found = slices.BinarySearchFunc(ids, after, compareIDs); found {
+			start++
+		}
+	}
+
+	files = make([]FileInfo, 0, min(limit, len(ids)-start))
+	for _, id := range ids[start:] {
+		if len(files) == limit {
+			return files, true, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, false, err
+		}
+
+		// the file may have been deleted since the ids have been taken
+		file, err := u.StorageController.File(id)
+		if err != nil {
+			continue
+		}
+		files = append(files, FileInfo{ID: id, Size: file.Size()})
+	}
+
+	return files, false, nil
+}
+
+func compareIDs(a, b uuid.UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
@@ -0,0 +1,59 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"os"
+)
+
+// PullFile supposed to be a request from FileSystem Manager
+func (u *UseCases) PullFile(ctx context.Context, fileID uuid.UUID, peer string) (size int64, checksum string, err error) {
+	if peer == "" {
+		return 0, "", newErrorWithMessage("peer to pull the file from is unknown")
+	}
+	if _, err := u.StorageController.File(fileID); err == nil {
+		return 0, "", newErrorWithMessage("file already exists")
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return 0, "", err
+	}
+	if u.StorageController.Draining() {
+		return 0, "", newErrorWithMessage("node is draining")
+	}
+
+	body, size, metadata, err := u.Replicator.Fetch(ctx, peer, fileID)
+	if err != nil {
+		return 0, "", err
+	}
+	defer body.Close()
+
+	checksum = metadata.Checksum
+	metadata.Replicas, metadata.Erasure = []string{peer}, nil
+	if err := u.StoreReplica(ctx, fileID, body, size, checksum, metadata); err != nil {
+		return 0, "", err
+	}
+
+	return size, checksum, nil
+}
+
+// PushFile supposed to be a request from FileSystem Manager
+func (u *UseCases) PushFile(ctx context.Context, fileID uuid.UUID, peer string) (size int64, checksum string, err error) {
+	if peer == "" {
+		return 0, "", newErrorWithMessage("peer to push the file to is unknown")
+	}
+
+	file, err := u.StorageController.File(fileID)
+	if err != nil {
+		return 0, "", err
+	}
+
+	// the checksum is reported to the FSM, so it's computed beforehand if the file has none yet
+	if checksum, err = u.checksum(file); err != nil {
+		return 0, "", err
+	}
+	if err := u.Replicator.Push(ctx, peer, file); err != nil {
+		return 0, "", err
+	}
+
+	return file.Size(), checksum, nil
+}
@@ -6,14 +6,21 @@ import (
 )
 
 // UpdateFile supposed to be a request from FileSystem Manager via Kafka
-func (u *UseCases) UpdateFile(ctx context.Context, host string, fileID uuid.UUID) (connectionID uuid.UUID, err error) {
+func (u *UseCases) UpdateFile(ctx context.Context, host string, fileID uuid.UUID, size int64, peers []string) (connectionID uuid.UUID, err error) {
 	file, err := u.StorageController.File(fileID)
 	if err != nil {
 		return connectionID, err
 	}
 
+	reservation, err := u.StorageController.ReserveFile(fileID, size)
+	if err != nil {
+		return connectionID, err
+	}
+
 	return u.FilesConnector.OpenConnection(&FileWithHost{
-		File: file,
-		Host: host,
+		File:        file,
+		Host:        host,
+		Reservation: reservation,
+		Peers:       peers,
 	})
 }
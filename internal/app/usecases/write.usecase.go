@@ -22,40 +22,46 @@ func (u *UseCases) Write(ctx context.Context, connectionID uuid.UUID, reader io.
 	if err != nil {
 		return err
 	}
+	// the writer locks the file, so it must be closed before the file is deleted on error
 	defer writer.Close()
 
 	if size <= 0 {
+		writer.Close()
 		return fmt.Errorf("request is empty: %w", u.handleWriteError(context.Background(), file.Host, file.File.ID()))
 	}
 
 	n, err := io.CopyN(writer, &contextReader{reader, ctx}, size)
 	if err != nil || n != size {
+		writer.Close()
 		return fmt.Errorf("unable to write full file: %w", errors.Join(err, u.handleWriteError(context.Background(), file.Host, file.File.ID())))
 	}
 
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	if len(file.Peers) > 0 {
+		go u.replicate(file.File, file.Peers)
+	}
+
 	return nil
 }
 
+// handleWriteError deletes the file from the FSM and from the node
 func (u *UseCases) handleWriteError(ctx context.Context, host string, fileID uuid.UUID) error {
-	link, err := url.JoinPath(host, fsmPath, fileID.String())
-	if err != nil {
-		return fmt.Errorf("failed to join path during handling /write error: %w", err)
-	}
-
-	_, err = u.client.R().
-		SetContext(ctx).
-		SetAuthScheme("Bearer").
-		SetAuthToken(u.serviceToken).
-		Delete(link)
-	if err != nil {
-		return fmt.Errorf("failed to delete file during handling /write error: %w", err)
+	var fsmErr error
+	if link, err := url.JoinPath(host, fsmPath, fileID.String()); err != nil {
+		fsmErr = fmt.Errorf("failed to join path during handling /write error: %w", err)
+	} else if err := u.FSM.Delete(ctx, link, fileID.String()); err != nil {
+		// the file id is the idempotency key, since the file is deleted at most once
+		fsmErr = fmt.Errorf("failed to delete file during handling /write error: %w", err)
 	}
 
 	if err := u.DeleteFile(ctx, fileID); err != nil {
-		return fmt.Errorf("failed to delete file after write error: %w", err)
+		return errors.Join(fsmErr, fmt.Errorf("failed to delete file after write error: %w", err))
 	}
 
-	return nil
+	return fsmErr
 }
 
 type contextReader struct {
@@ -5,20 +5,63 @@ import (
 	"fmt"
 	"github.com/ilyakaznacheev/cleanenv"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type GC struct {
-	SleepInMinutes     uint `env:"DISPOSAL_SLEEP_IN_MINUTES" env-default:"20"`
-	KeepAliveInMinutes uint `env:"DISPOSAL_KEEP_ALIVE_IN_MINUTES" env-default:"10"`
+	SleepInMinutes           uint `env:"DISPOSAL_SLEEP_IN_MINUTES" env-default:"20"`
+	KeepAliveInMinutes       uint `env:"DISPOSAL_KEEP_ALIVE_IN_MINUTES" env-default:"10"`
+	ExpirationSleepInMinutes uint `env:"EXPIRATION_SLEEP_IN_MINUTES" env-default:"1"`
 }
 
+// RabbitMQ configures the AMQP transport, Host and Token identify the node to the FSM and its peers.
 type RabbitMQ struct {
-	Host      string `env:"FOR_RABBIT_HOST"`
-	URN       string `env:"RABBIT_URN"`
+	Host  string `env:"FOR_RABBIT_HOST"`
+	URN   string `env:"RABBIT_URN"`
+	Token string `env:"EXCHANGE_TOKEN"`
+	// Durable queues keep pending requests over broker restarts, direct and topic exchanges need RoutingKeys
+	Durable       bool     `env:"RABBIT_DURABLE" env-default:"false"`
+	QueueType     string   `env:"RABBIT_QUEUE_TYPE" env-default:"classic"`
+	ExchangeType  string   `env:"RABBIT_EXCHANGE_TYPE" env-default:"fanout"`
+	RoutingKeys   []string `env:"RABBIT_ROUTING_KEYS"`
+	PrefetchCount int      `env:"RABBIT_PREFETCH_COUNT" env-default:"1"`
+}
+
+// NATS configures the NATS transport, the node subscribes to Topic in the queue group named QueueName.
+type NATS struct {
+	Servers string `env:"NATS_SERVERS" env-default:"nats://nats:4222"`
+	// JetStream keeps pending requests in a stream provisioned on start.
+	JetStream bool          `env:"NATS_JETSTREAM" env-default:"false"`
+	AckWait   time.Duration `env:"NATS_ACK_WAIT" env-default:"30s"`
+}
+
+// GoChannel configures the in-process transport of tests and single-binary deployments.
+type GoChannel struct {
+	BufferSize int64 `env:"GOCHANNEL_BUFFER_SIZE" env-default:"64"`
+}
+
+// Queue configures how requests of the FSM are consumed, Topic and QueueName keep their RABBIT_ names.
+type Queue struct {
+	// Transport is one of "amqp", "nats" and "gochannel".
+	Transport string `env:"QUEUE_TRANSPORT" env-default:"amqp"`
 	Topic     string `env:"RABBIT_TOPIC"`
 	QueueName string `env:"RABBIT_QUEUE_NAME"`
-	Token     string `env:"EXCHANGE_TOKEN"`
+	// ResponseMode is either "http" or "queue", where the FSM reverts responses in RevertTimeout
+	ResponseMode  string        `env:"QUEUE_RESPONSE_MODE" env-default:"http"`
+	ResponseTopic string        `env:"QUEUE_RESPONSE_TOPIC" env-default:"fs_to_fsm"`
+	RevertTimeout time.Duration `env:"QUEUE_REVERT_TIMEOUT" env-default:"1m"`
+	// DedupPath journals responses of redelivered requests, an empty path keeps them in memory only
+	DedupPath     string `env:"QUEUE_DEDUP_PATH" env-default:"./queue.dedup"`
+	DedupCapacity int    `env:"QUEUE_DEDUP_CAPACITY" env-default:"10000"`
+	// Messages failing MaxAttempts times are moved to DeadLetterQueue, "<QueueName>.dead-letters" if empty.
+	DeadLetterQueue string `env:"QUEUE_DEAD_LETTER_QUEUE"`
+	MaxAttempts     int    `env:"QUEUE_MAX_ATTEMPTS" env-default:"5"`
+	// Concurrency is the number of requests processed at once, the gochannel transport processes one at a time.
+	Concurrency int `env:"QUEUE_CONCURRENCY" env-default:"4"`
+	// BatchConcurrency is the number of sub-requests of a batch processed at once.
+	BatchConcurrency int `env:"QUEUE_BATCH_CONCURRENCY" env-default:"16"`
 }
 
 type Handler struct {
@@ -33,6 +76,104 @@ type Storage struct {
 	StorageSize   int64  `env:"STORAGE_SIZE"`
 	MinBufferSize int    `env:"MIN_BUFFER_SIZE" env-default:"65536"`
 	MaxBufferSize int    `env:"MAX_BUFFER_SIZE" env-default:"5242880"`
+	// Disks overrides StoragePath and StorageSize when several data directories are used.
+	Disks             Disks         `env:"STORAGE_DISKS"`
+	Placement         string        `env:"STORAGE_PLACEMENT" env-default:"free-space"`
+	DiskCheckInterval time.Duration `env:"STORAGE_DISK_CHECK_INTERVAL" env-default:"30s"`
+	// the node is read-only from HighWatermark of storage usage until it drops below LowWatermark
+	LowWatermark       float64       `env:"STORAGE_LOW_WATERMARK" env-default:"0.85"`
+	HighWatermark      float64       `env:"STORAGE_HIGH_WATERMARK" env-default:"0.95"`
+	SpaceCheckInterval time.Duration `env:"STORAGE_SPACE_CHECK_INTERVAL" env-default:"10s"`
+	Layout
+}
+
+type Disk struct {
+	Path string
+	Size int64
+	Cold bool
+}
+
+// Disks is a comma separated list of data directories with their capacities, e.g. "/mnt/a:1000,/mnt/c:8000:cold".
+type Disks []Disk
+
+func (d *Disks) SetValue(s string) error {
+	*d = nil
+	if s == "" {
+		return nil
+	}
+
+	for _, raw := range strings.Split(s, ",") {
+		parts := strings.Split(strings.TrimSpace(raw), ":")
+		if len(parts) < 2 || len(parts) > 3 || (len(parts) == 3 && parts[2] != "cold") {
+			return fmt.Errorf("disk %q must be in the form path:size[:cold]", raw)
+		}
+
+		size, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("unable to parse size of disk %q: %w", parts[0], err)
+		}
+
+		*d = append(*d, Disk{Path: parts[0], Size: size, Cold: len(parts) == 3})
+	}
+
+	return nil
+}
+
+// Tiering moves files which haven't been read for ColdAfterInDays to the cold disks, or shards them there.
+type Tiering struct {
+	ColdAfterInDays       uint `env:"TIER_COLD_AFTER_IN_DAYS" env-default:"30"`
+	SleepInMinutes        uint `env:"TIER_SLEEP_IN_MINUTES" env-default:"60"`
+	PromoteOnAccess       bool `env:"TIER_PROMOTE_ON_ACCESS" env-default:"false"`
+	ErasureDataShards     uint `env:"TIER_EC_DATA_SHARDS" env-default:"0"`
+	ErasureParityShards   uint `env:"TIER_EC_PARITY_SHARDS" env-default:"2"`
+	RebuildSleepInMinutes uint `env:"TIER_EC_REBUILD_SLEEP_IN_MINUTES" env-default:"10"`
+	// ScrubIntervalInHours is how often the shards are verified against their checksums, zero disables it
+	ScrubIntervalInHours uint `env:"TIER_EC_SCRUB_INTERVAL_IN_HOURS" env-default:"24"`
+}
+
+// Layout configures the fan-out of blobs inside StoragePath, e.g. depth 2 and width 2 stand for "ab/cd/<uuid>".
+type Layout struct {
+	ShardDepth              int  `env:"STORAGE_SHARD_DEPTH" env-default:"2"`
+	ShardWidth              int  `env:"STORAGE_SHARD_WIDTH" env-default:"2"`
+	MigrateLayout           bool `env:"STORAGE_MIGRATE_LAYOUT" env-default:"false"`
+	MigrationSleepInMinutes uint `env:"STORAGE_MIGRATION_SLEEP_IN_MINUTES" env-default:"1"`
+}
+
+// FSM callbacks are retried with exponential backoff behind a circuit breaker.
+type FSM struct {
+	Host              string        `env:"FSM_HOST"`
+	HeartbeatInterval time.Duration `env:"FSM_HEARTBEAT_INTERVAL" env-default:"10s"`
+	Timeout           time.Duration `env:"FSM_TIMEOUT" env-default:"10s"`
+	Retries           int           `env:"FSM_RETRIES" env-default:"5"`
+	Backoff           time.Duration `env:"FSM_BACKOFF" env-default:"200ms"`
+	MaxBackoff        time.Duration `env:"FSM_MAX_BACKOFF" env-default:"10s"`
+	BreakerThreshold  int           `env:"FSM_BREAKER_THRESHOLD" env-default:"5"`
+	BreakerCooldown   time.Duration `env:"FSM_BREAKER_COOLDOWN" env-default:"30s"`
+	// ContentType encodes notifications and heartbeats, see api/queue for the schemas.
+	ContentType string `env:"FSM_CONTENT_TYPE" env-default:"application/x-gob"`
+}
+
+// Replication pushes written files to the peers designated by the FSM.
+type Replication struct {
+	Timeout time.Duration `env:"REPLICATION_TIMEOUT" env-default:"10m"`
+}
+
+// Ingest downloads files from the urls sent by the FSM.
+type Ingest struct {
+	Timeout      time.Duration `env:"INGEST_TIMEOUT" env-default:"1h"`
+	MaxRedirects int           `env:"INGEST_MAX_REDIRECTS" env-default:"5"`
+	// AllowPrivate lets urls resolve to loopback and private addresses, which is meant for development only.
+	AllowPrivate bool `env:"INGEST_ALLOW_PRIVATE" env-default:"false"`
+}
+
+// Signing verifies urls signed by the FSM, which let users read files without a connection opened beforehand.
+type Signing struct {
+	// Keys are the accepted keys by their ids, e.g. "2024-06:secret,2024-07:secret", EXCHANGE_TOKEN if empty
+	Keys map[string]string `env:"SIGNING_KEYS"`
+	// KeyID is the id of the key signing new urls, it may be empty if there is a single key.
+	KeyID string `env:"SIGNING_KEY_ID"`
+	// TrustedProxies are the CIDRs of the proxies allowed to forward the address of the client
+	TrustedProxies []string `env:"SIGNING_TRUSTED_PROXIES"`
 }
 
 type Logger struct {
@@ -42,9 +183,17 @@ type Logger struct {
 type Config struct {
 	Logger
 	GC
+	Queue
 	RabbitMQ
+	NATS
+	GoChannel
 	Handler
 	Storage
+	Tiering
+	FSM
+	Replication
+	Ingest
+	Signing
 	Env string `env:"ENV" env-default:"dev"`
 }
 
@@ -42,8 +42,8 @@ func (m *DefaultMap[K, V]) Delete(key K) error {
 	m.mx.Lock()
 	defer m.mx.Unlock()
 
-	if _, ok := m.m[key]; ok {
-		return os.ErrExist
+	if _, ok := m.m[key]; !ok {
+		return os.ErrNotExist
 	}
 
 	delete(m.m, key)
@@ -51,6 +51,13 @@ func (m *DefaultMap[K, V]) Delete(key K) error {
 	return nil
 }
 
+func (m *DefaultMap[K, V]) Len() int {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	return len(m.m)
+}
+
 // All creates a copy of the underlying map and returns an iterator over it. Use it wisely.
 func (m *DefaultMap[K, V]) All() iter.Seq2[K, V] {
 	m.mx.RLock()
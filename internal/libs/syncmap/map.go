@@ -7,4 +7,5 @@ type Map[K comparable, V any] interface {
 	Set(key K, value V) error
 	Delete(key K) error
 	All() iter.Seq2[K, V]
+	Len() int
 }